@@ -0,0 +1,240 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// AdminAuditGroup is the group into which AdminHandler records every action.
+// It is pinned so that the audit trail survives eviction.
+const AdminAuditGroup = "tracelog.admin"
+
+// AdminOptions configures AdminHandler.
+type AdminOptions struct {
+	// OnSnapshot, if set, is called with each snapshot taken through the
+	// API, e.g. to persist it.
+	OnSnapshot func(snapshot []byte)
+}
+
+// AdminHandler returns an http.Handler exposing a JSON API to control t:
+//
+//	GET  /status    enabled state, pinned groups, minimum levels and stats
+//	POST /enable    enable the tracer
+//	POST /disable   disable the tracer
+//	POST /level     {"group": "db", "level": "WARN"}, an empty level clears it
+//	POST /clear     {"group": "db", "span": "query"}, without span clears the group
+//	POST /reset     clear all groups but the audit group
+//	POST /pin       {"group": "db"}
+//	POST /unpin     {"group": "db"}
+//	POST /snapshot  capture the tracer contents as raw JSON and return them
+//	GET  /snapshot  return the last snapshot
+//
+// The handler is wrapped with RequireAuth and auth, so viewers may only
+// read the status and the last snapshot. Every action is recorded in
+// AdminAuditGroup along with the caller. AdminHandler panics if auth is nil.
+func AdminHandler(t Tracer, auth Authenticator, opts AdminOptions) http.Handler {
+	if auth == nil {
+		panic("tracer: AdminHandler requires an Authenticator")
+	}
+	t.Pin(AdminAuditGroup)
+
+	a := &admin{tracer: t, opts: opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", a.status)
+	mux.HandleFunc("POST /enable", a.enable)
+	mux.HandleFunc("POST /disable", a.disable)
+	mux.HandleFunc("POST /level", a.level)
+	mux.HandleFunc("POST /clear", a.clear)
+	mux.HandleFunc("POST /reset", a.reset)
+	mux.HandleFunc("POST /pin", a.pin)
+	mux.HandleFunc("POST /unpin", a.unpin)
+	mux.HandleFunc("POST /snapshot", a.takeSnapshot)
+	mux.HandleFunc("GET /snapshot", a.lastSnapshot)
+	return RequireAuth(mux, auth)
+}
+
+type admin struct {
+	tracer Tracer
+	opts   AdminOptions
+
+	mu       sync.Mutex
+	snapshot []byte
+}
+
+type adminRequest struct {
+	Group string `json:"group"`
+	Span  string `json:"span"`
+	Level string `json:"level"`
+}
+
+func (a *admin) status(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, http.StatusOK, a.tracer.Stats())
+}
+
+func (a *admin) enable(w http.ResponseWriter, r *http.Request) {
+	a.tracer.Enable()
+	a.audit(r, "enable", "tracer enabled")
+	writeAdminOK(w)
+}
+
+func (a *admin) disable(w http.ResponseWriter, r *http.Request) {
+	a.tracer.Disable()
+	a.audit(r, "disable", "tracer disabled")
+	writeAdminOK(w)
+}
+
+func (a *admin) level(w http.ResponseWriter, r *http.Request) {
+	req, ok := readAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Group == AdminAuditGroup {
+		writeAdminError(w, http.StatusBadRequest, fmt.Errorf("the audit group level cannot be changed"))
+		return
+	}
+	if err := a.tracer.SetMinLevel(req.Group, req.Level); err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Level == "" {
+		a.audit(r, "level", fmt.Sprintf("min level of group %q cleared", req.Group))
+	} else {
+		a.audit(r, "level", fmt.Sprintf("min level of group %q set to %s", req.Group, req.Level))
+	}
+	writeAdminOK(w)
+}
+
+func (a *admin) clear(w http.ResponseWriter, r *http.Request) {
+	req, ok := readAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Group == AdminAuditGroup {
+		writeAdminError(w, http.StatusBadRequest, fmt.Errorf("the audit group cannot be cleared"))
+		return
+	}
+	if req.Span == "" {
+		a.tracer.DeleteGroup(req.Group)
+		a.audit(r, "clear", fmt.Sprintf("group %q cleared", req.Group))
+	} else {
+		a.tracer.DeleteSpan(req.Group, req.Span)
+		a.audit(r, "clear", fmt.Sprintf("span %q of group %q cleared", req.Span, req.Group))
+	}
+	writeAdminOK(w)
+}
+
+func (a *admin) reset(w http.ResponseWriter, r *http.Request) {
+	// keep the audit trail across resets
+	for group := range a.tracer.Groups() {
+		if group != AdminAuditGroup {
+			a.tracer.DeleteGroup(group)
+		}
+	}
+	a.audit(r, "reset", "all groups cleared")
+	writeAdminOK(w)
+}
+
+func (a *admin) pin(w http.ResponseWriter, r *http.Request) {
+	req, ok := readAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	a.tracer.Pin(req.Group)
+	a.audit(r, "pin", fmt.Sprintf("group %q pinned", req.Group))
+	writeAdminOK(w)
+}
+
+func (a *admin) unpin(w http.ResponseWriter, r *http.Request) {
+	req, ok := readAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Group == AdminAuditGroup {
+		writeAdminError(w, http.StatusBadRequest, fmt.Errorf("the audit group cannot be unpinned"))
+		return
+	}
+	a.tracer.Unpin(req.Group)
+	a.audit(r, "unpin", fmt.Sprintf("group %q unpinned", req.Group))
+	writeAdminOK(w)
+}
+
+func (a *admin) takeSnapshot(w http.ResponseWriter, r *http.Request) {
+	a.audit(r, "snapshot", "snapshot taken")
+
+	var buf bytes.Buffer
+	if err := a.tracer.WriteJSON(&buf, JSONOptions{Raw: true}); err != nil {
+		writeAdminError(w, http.StatusInternalServerError, err)
+		return
+	}
+	snapshot := buf.Bytes()
+
+	a.mu.Lock()
+	a.snapshot = snapshot
+	a.mu.Unlock()
+
+	if a.opts.OnSnapshot != nil {
+		a.opts.OnSnapshot(snapshot)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(snapshot)
+}
+
+func (a *admin) lastSnapshot(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	snapshot := a.snapshot
+	a.mu.Unlock()
+
+	if snapshot == nil {
+		writeAdminError(w, http.StatusNotFound, fmt.Errorf("no snapshot taken"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(snapshot)
+}
+
+// audit records an admin action in AdminAuditGroup, bypassing the
+// configuration it may have just changed.
+func (a *admin) audit(r *http.Request, action, detail string) {
+	who := "anonymous"
+	if p, ok := PrincipalFromContext(r.Context()); ok {
+		who = fmt.Sprintf("%s (%s)", p.Name, p.Role)
+	}
+	msg := fmt.Sprintf("%s by %s from %s", detail, who, r.RemoteAddr)
+	if t, ok := a.tracer.(*tracer); ok {
+		// recorded even when disabled, sampled or filtered out
+		t.audit(AdminAuditGroup, action, msg)
+		return
+	}
+	a.tracer.Trace(AdminAuditGroup, action).Info("%s", msg)
+}
+
+func readAdminRequest(w http.ResponseWriter, r *http.Request) (adminRequest, bool) {
+	var req adminRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return req, false
+	}
+	if req.Group == "" {
+		writeAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid request: empty group"))
+		return req, false
+	}
+	return req, true
+}
+
+func writeAdminOK(w http.ResponseWriter) {
+	writeAdminJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	writeAdminJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
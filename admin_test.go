@@ -0,0 +1,156 @@
+package tracer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAdminHandler(t *testing.T) {
+	tcr := NewTracerWithSizes(3, 10, 4)
+
+	var snapshots [][]byte
+	h := AdminHandler(tcr, BasicAuth(map[string]BasicUser{
+		"ops": {Password: "pw", Role: RoleAdmin},
+		"dev": {Password: "pw", Role: RoleViewer},
+	}), AdminOptions{
+		OnSnapshot: func(snapshot []byte) { snapshots = append(snapshots, snapshot) },
+	})
+
+	call := func(user, method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.SetBasicAuth(user, "pw")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+
+	t.Run("auth", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("POST", "/disable", nil))
+		assertEqual(t, http.StatusUnauthorized, rec.Code)
+		assertTrue(t, tcr.IsEnabled())
+		assertEqual(t, http.StatusUnauthorized, call("nobody", "GET", "/status", "").Code)
+	})
+
+	t.Run("enable and disable", func(t *testing.T) {
+		assertEqual(t, http.StatusForbidden, call("dev", "POST", "/disable", "").Code)
+		assertTrue(t, tcr.IsEnabled())
+
+		assertEqual(t, http.StatusOK, call("ops", "POST", "/disable", "").Code)
+		assertFalse(t, tcr.IsEnabled())
+		assertEqual(t, http.StatusOK, call("ops", "POST", "/enable", "").Code)
+		assertTrue(t, tcr.IsEnabled())
+	})
+
+	t.Run("level", func(t *testing.T) {
+		assertEqual(t, http.StatusBadRequest, call("ops", "POST", "/level", `{"group":"db","level":"LOUD"}`).Code)
+		assertEqual(t, http.StatusBadRequest, call("ops", "POST", "/level", `{"group":"db"`).Code)
+		assertEqual(t, http.StatusOK, call("ops", "POST", "/level", `{"group":"db","level":"WARN"}`).Code)
+
+		tcr.Trace("db", "query").Info("select")
+		tcr.Trace("db", "query").Warn("slow")
+		assertEqual(t, 1, len(slices.Collect(tcr.Entries("db", "query"))))
+
+		var st Stats
+		rec := call("dev", "GET", "/status", "")
+		assertNoError(t, json.Unmarshal(rec.Body.Bytes(), &st))
+		assertEqual(t, map[string]string{"db": "WARN"}, st.MinLevels)
+		assertEqual(t, uint64(1), st.DroppedLevel)
+
+		assertEqual(t, http.StatusOK, call("ops", "POST", "/level", `{"group":"db","level":""}`).Code)
+		tcr.Trace("db", "query").Info("select")
+		assertEqual(t, 2, len(slices.Collect(tcr.Entries("db", "query"))))
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		tcr.Trace("db", "conn").Info("open")
+		assertEqual(t, http.StatusOK, call("ops", "POST", "/clear", `{"group":"db","span":"query"}`).Code)
+		assertEqual(t, []string{"conn"}, slices.Collect(tcr.Spans("db")))
+
+		assertEqual(t, http.StatusOK, call("ops", "POST", "/clear", `{"group":"db"}`).Code)
+		assertFalse(t, slices.Contains(slices.Collect(tcr.Groups()), "db"))
+
+		assertEqual(t, http.StatusBadRequest, call("ops", "POST", "/clear", `{"group":"`+AdminAuditGroup+`"}`).Code)
+		for _, path := range []string{"/level", "/clear", "/pin", "/unpin"} {
+			assertEqual(t, http.StatusBadRequest, call("ops", "POST", path, `{"group":"","span":"query","level":"WARN"}`).Code)
+		}
+	})
+
+	t.Run("pin", func(t *testing.T) {
+		assertEqual(t, http.StatusOK, call("ops", "POST", "/pin", `{"group":"heartbeat"}`).Code)
+		tcr.Trace("heartbeat", "tick").Info("ok")
+		for _, group := range []string{"a", "b", "c", "d"} {
+			tcr.Trace(group, "run").Info("start")
+		}
+
+		groups := slices.Collect(tcr.Groups())
+		assertTrue(t, slices.Contains(groups, "heartbeat"))
+		assertTrue(t, slices.Contains(groups, AdminAuditGroup))
+		assertEqual(t, 3, len(groups))
+
+		assertEqual(t, http.StatusBadRequest, call("ops", "POST", "/unpin", `{"group":"`+AdminAuditGroup+`"}`).Code)
+		assertEqual(t, http.StatusOK, call("ops", "POST", "/unpin", `{"group":"heartbeat"}`).Code)
+		assertEqual(t, []string{AdminAuditGroup}, tcr.Stats().Pinned)
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		assertEqual(t, http.StatusNotFound, call("dev", "GET", "/snapshot", "").Code)
+
+		rec := call("ops", "POST", "/snapshot", "")
+		assertEqual(t, http.StatusOK, rec.Code)
+		var out map[string]map[string][]jsonEntry
+		assertNoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+		assertTrue(t, len(out[AdminAuditGroup]) > 0)
+
+		assertEqual(t, 1, len(snapshots))
+		assertEqual(t, rec.Body.String(), call("dev", "GET", "/snapshot", "").Body.String())
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		tcr.Trace("api", "rpc").Info("getUser")
+		assertEqual(t, http.StatusOK, call("ops", "POST", "/reset", "").Code)
+		assertEqual(t, []string{AdminAuditGroup}, slices.Collect(tcr.Groups()))
+	})
+
+	t.Run("audit", func(t *testing.T) {
+		var messages []string
+		for entry := range tcr.Entries(AdminAuditGroup, "disable") {
+			messages = append(messages, entry.Message())
+		}
+		assertEqual(t, 1, len(messages))
+		assertTrue(t, strings.HasPrefix(messages[0], "tracer disabled by ops (admin) from "))
+
+		spans := slices.Collect(tcr.Spans(AdminAuditGroup))
+		slices.Sort(spans)
+		assertEqual(t, []string{"clear", "disable", "enable", "level", "pin", "reset", "snapshot", "unpin"}, spans)
+	})
+
+	t.Run("audit while disabled", func(t *testing.T) {
+		assertEqual(t, http.StatusOK, call("ops", "POST", "/disable", "").Code)
+		defer tcr.Enable()
+		tcr.SetSampling(SamplingRule{Sampler: ProbabilitySampler(0), IncludeErrors: true})
+		defer tcr.SetSampling()
+		assertNoError(t, tcr.SetMinLevel(AdminAuditGroup, LevelError))
+		defer tcr.SetMinLevel(AdminAuditGroup, "")
+
+		assertEqual(t, http.StatusOK, call("ops", "POST", "/level", `{"group":"db","level":"ERROR"}`).Code)
+		assertEqual(t, http.StatusOK, call("ops", "POST", "/clear", `{"group":"db"}`).Code)
+
+		audited := func(span, prefix string) bool {
+			for entry := range tcr.Entries(AdminAuditGroup, span) {
+				if strings.HasPrefix(entry.Message(), prefix) {
+					return true
+				}
+			}
+			return false
+		}
+		disabled := slices.Collect(tcr.Entries(AdminAuditGroup, "disable"))
+		assertEqual(t, uint32(2), disabled[0].Count())
+		assertTrue(t, audited("level", `min level of group "db" set to ERROR by ops (admin)`))
+		assertTrue(t, audited("clear", `group "db" cleared by ops (admin)`))
+	})
+}
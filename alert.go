@@ -0,0 +1,441 @@
+package tracer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AlertGroup is the group into which Alerts records the alerts it fires
+// and resolves, and the errors of its notifiers. Its entries never match
+// alert rules.
+const AlertGroup = "tracelog.alerts"
+
+// AlertRule describes when an alert fires. An entry matches the rule when
+// it matches every non-zero filter field. By default the rule fires on the
+// first matching entry, and resolves once a Window passed without one.
+// Rules see every message logged, including those dropped by sampling.
+type AlertRule struct {
+	// Name identifies the rule in alerts, and must be unique.
+	Name string
+
+	// Group and Span match group and span names by prefix, like the ToMap
+	// filters.
+	Group string
+	Span  string
+
+	// Level matches entries of exactly this level.
+	Level string
+
+	// Message matches entries whose message matches it.
+	Message *regexp.Regexp
+
+	// Threshold fires the rule when at least that many entries match
+	// within Window, which makes for a count, or a rate, condition. The
+	// rule resolves when the count falls below it. Defaults to 1.
+	Threshold int
+
+	// Window is the period over which entries are counted, defaults to a
+	// minute.
+	Window time.Duration
+
+	// Absent turns the rule into a heartbeat: it fires when no entry
+	// matched within Window, and resolves on the next matching entry.
+	Absent bool
+
+	// Cooldown is the minimum time between two firings of the rule, so
+	// that a flapping condition does not page repeatedly. A firing rule
+	// does not fire again until it resolves.
+	Cooldown time.Duration
+}
+
+func (r AlertRule) matches(entry LogEntry) bool {
+	if r.Group != "" && !strings.HasPrefix(entry.Group(), r.Group) {
+		return false
+	}
+	if r.Span != "" && !strings.HasPrefix(entry.Span(), r.Span) {
+		return false
+	}
+	if r.Level != "" && entry.Level() != r.Level {
+		return false
+	}
+	return r.Message == nil || r.Message.MatchString(entry.Message())
+}
+
+func (r AlertRule) describe(count int) string {
+	level := ""
+	if r.Level != "" {
+		level = r.Level + " "
+	}
+	if r.Absent {
+		return fmt.Sprintf("no matching %sentry within %s", level, r.Window)
+	}
+	return fmt.Sprintf("%d matching %sentries within %s", count, level, r.Window)
+}
+
+// AlertState is the state of an alert.
+type AlertState string
+
+const (
+	AlertFiring   AlertState = "firing"
+	AlertResolved AlertState = "resolved"
+)
+
+// Alert is the notification of a rule firing or resolving.
+type Alert struct {
+	Rule        string     `json:"rule"`
+	State       AlertState `json:"state"`
+	Description string     `json:"description"`
+	Count       int        `json:"count"`
+	Time        time.Time  `json:"time"`
+
+	// Group, Span, Level and Message are those of the last matching entry,
+	// if any.
+	Group   string `json:"group,omitempty"`
+	Span    string `json:"span,omitempty"`
+	Level   string `json:"level,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Notifier delivers alerts.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// NotifierFunc adapts a function to the Notifier interface.
+type NotifierFunc func(ctx context.Context, alert Alert) error
+
+func (f NotifierFunc) Notify(ctx context.Context, alert Alert) error {
+	return f(ctx, alert)
+}
+
+// WebhookNotifier returns a Notifier posting each alert as JSON to url with
+// client, or http.DefaultClient if nil. Responses other than 2xx are
+// errors.
+func WebhookNotifier(url string, client *http.Client) Notifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return NotifierFunc(func(ctx context.Context, alert Alert) error {
+		body, err := json.Marshal(alert)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("tracer: webhook responded with %s", resp.Status)
+		}
+		return nil
+	})
+}
+
+// WriterNotifier returns a Notifier writing each alert as a line to w.
+func WriterNotifier(w io.Writer) Notifier {
+	var mu sync.Mutex
+	return NotifierFunc(func(ctx context.Context, alert Alert) error {
+		line := fmt.Sprintf("%s %s %s: %s", alert.Time.UTC().Format(time.RFC3339),
+			strings.ToUpper(string(alert.State)), alert.Rule, alert.Description)
+		if alert.Message != "" {
+			line += fmt.Sprintf(", last [%s] %s/%s %q", alert.Level, alert.Group, alert.Span, alert.Message)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := io.WriteString(w, line+"\n")
+		return err
+	})
+}
+
+// AlertOptions configures NewAlerts.
+type AlertOptions struct {
+	Rules     []AlertRule
+	Notifiers []Notifier
+
+	// Interval is the period at which rules are evaluated for resolution
+	// and absence, defaults to a second. Count rules fire as soon as an
+	// entry takes them over their threshold.
+	Interval time.Duration
+
+	// Timeout bounds each notification, defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// Alerts evaluates alert rules over the entries of a tracer, as they are
+// logged, and notifies when rules fire or resolve. Notifications are
+// delivered in order by a background goroutine, so that loggers never wait
+// for notifiers; they are dropped, and the drop recorded, when it falls
+// behind.
+type Alerts struct {
+	tracer      Tracer
+	opts        AlertOptions
+	unsubscribe func()
+	queue       chan Alert
+	stop        chan struct{}
+	done        sync.WaitGroup
+
+	mu     sync.Mutex
+	rules  []*alertRule
+	closed bool
+}
+
+type alertRule struct {
+	AlertRule
+	firing    bool
+	lastFired time.Time
+	lastSeen  time.Time
+	last      LogEntry
+	times     []time.Time // of the latest matching entries, at most Threshold
+}
+
+// NewAlerts starts evaluating opts.Rules over the entries of t, until
+// Close.
+func NewAlerts(t Tracer, opts AlertOptions) (*Alerts, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	now := time.Now()
+	names := make(map[string]bool, len(opts.Rules))
+	rules := make([]*alertRule, len(opts.Rules))
+	for i, rule := range opts.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("tracer: alert rule %d has no name", i)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("tracer: duplicate alert rule %q", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.Threshold < 1 {
+			rule.Threshold = 1
+		}
+		if rule.Window <= 0 {
+			rule.Window = time.Minute
+		}
+		// heartbeats get a window of grace on start
+		rules[i] = &alertRule{AlertRule: rule, lastSeen: now}
+	}
+
+	a := &Alerts{
+		tracer: t,
+		opts:   opts,
+		queue:  make(chan Alert, 64),
+		stop:   make(chan struct{}),
+		rules:  rules,
+	}
+	a.done.Add(2)
+	go a.deliver()
+	go a.tick()
+	a.unsubscribe = observeEntries(t, a.observe)
+	return a, nil
+}
+
+// Close stops evaluating rules, and returns once the pending notifications
+// are delivered.
+func (a *Alerts) Close() {
+	a.unsubscribe()
+	a.mu.Lock()
+	a.closed = true
+	a.mu.Unlock()
+	close(a.stop)
+	a.done.Wait()
+}
+
+// Firing returns the names of the rules currently firing.
+func (a *Alerts) Firing() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var names []string
+	for _, rule := range a.rules {
+		if rule.firing {
+			names = append(names, rule.Name)
+		}
+	}
+	return names
+}
+
+// observe evaluates the rules matching entry.
+func (a *Alerts) observe(entry LogEntry) {
+	if entry.Group() == AlertGroup {
+		return
+	}
+	now := entry.Time()
+	var alerts []Alert
+
+	a.mu.Lock()
+	for _, rule := range a.rules {
+		if !rule.matches(entry) {
+			continue
+		}
+		rule.last = entry
+		rule.lastSeen = now
+		if rule.Absent {
+			if rule.firing {
+				alerts = append(alerts, rule.resolve(now, 1))
+			}
+			continue
+		}
+
+		rule.times = append(rule.times, now)
+		if len(rule.times) > rule.Threshold {
+			rule.times = rule.times[1:]
+		}
+		rule.prune(now)
+		if len(rule.times) >= rule.Threshold {
+			if alert, ok := rule.fire(now, len(rule.times)); ok {
+				alerts = append(alerts, alert)
+			}
+		}
+	}
+	a.mu.Unlock()
+
+	a.enqueue(alerts)
+}
+
+// evaluate resolves the count rules whose entries left their window, and
+// fires the heartbeat rules without a recent entry.
+func (a *Alerts) evaluate(now time.Time) {
+	var alerts []Alert
+
+	a.mu.Lock()
+	for _, rule := range a.rules {
+		if rule.Absent {
+			if !rule.firing && now.Sub(rule.lastSeen) > rule.Window {
+				if alert, ok := rule.fire(now, 0); ok {
+					alerts = append(alerts, alert)
+				}
+			}
+			continue
+		}
+
+		rule.prune(now)
+		if rule.firing && len(rule.times) < rule.Threshold {
+			alerts = append(alerts, rule.resolve(now, len(rule.times)))
+		}
+	}
+	a.mu.Unlock()
+
+	a.enqueue(alerts)
+}
+
+// prune drops the times which left the window. The caller must hold a.mu.
+func (r *alertRule) prune(now time.Time) {
+	i := 0
+	for i < len(r.times) && now.Sub(r.times[i]) > r.Window {
+		i++
+	}
+	r.times = r.times[i:]
+}
+
+// fire fires the rule unless it is firing or cooling down. The caller must
+// hold a.mu.
+func (r *alertRule) fire(now time.Time, count int) (Alert, bool) {
+	if r.firing || (!r.lastFired.IsZero() && now.Sub(r.lastFired) < r.Cooldown) {
+		return Alert{}, false
+	}
+	r.firing = true
+	r.lastFired = now
+	return r.alert(AlertFiring, now, count), true
+}
+
+// resolve resolves the firing rule. The caller must hold a.mu.
+func (r *alertRule) resolve(now time.Time, count int) Alert {
+	r.firing = false
+	return r.alert(AlertResolved, now, count)
+}
+
+func (r *alertRule) alert(state AlertState, now time.Time, count int) Alert {
+	alert := Alert{
+		Rule:        r.Name,
+		State:       state,
+		Description: r.describe(count),
+		Count:       count,
+		Time:        now,
+	}
+	if r.last != nil {
+		alert.Group = r.last.Group()
+		alert.Span = r.last.Span()
+		alert.Level = r.last.Level()
+		alert.Message = r.last.Message()
+	}
+	return alert
+}
+
+func (a *Alerts) enqueue(alerts []Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+
+	var dropped []Alert
+	a.mu.Lock()
+	if !a.closed {
+		for _, alert := range alerts {
+			select {
+			case a.queue <- alert:
+			default:
+				dropped = append(dropped, alert)
+			}
+		}
+	}
+	a.mu.Unlock()
+
+	// logging calls observe, so not under a.mu
+	for _, alert := range dropped {
+		a.tracer.Trace(AlertGroup, "notify").Error("dropped %s alert of rule %s, notifiers are behind", alert.State, alert.Rule)
+	}
+}
+
+func (a *Alerts) tick() {
+	defer a.done.Done()
+	defer close(a.queue)
+
+	ticker := time.NewTicker(a.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stop:
+			return
+		case now := <-ticker.C:
+			a.evaluate(now)
+		}
+	}
+}
+
+func (a *Alerts) deliver() {
+	defer a.done.Done()
+
+	for alert := range a.queue {
+		if alert.State == AlertFiring {
+			a.tracer.Trace(AlertGroup, alert.Rule).Warn("%s: %s", alert.State, alert.Description)
+		} else {
+			a.tracer.Trace(AlertGroup, alert.Rule).Info("%s: %s", alert.State, alert.Description)
+		}
+		for _, n := range a.opts.Notifiers {
+			ctx, cancel := context.WithTimeout(context.Background(), a.opts.Timeout)
+			if err := n.Notify(ctx, alert); err != nil {
+				a.tracer.Trace(AlertGroup, "notify").Error("notifying %s alert of rule %s: %v", alert.State, alert.Rule, err)
+			}
+			cancel()
+		}
+	}
+}
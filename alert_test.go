@@ -0,0 +1,158 @@
+package tracer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlerts(t *testing.T) {
+	tcr := NewTracer()
+	notified := make(chan Alert, 16)
+	alerts, err := NewAlerts(tcr, AlertOptions{
+		Rules: []AlertRule{
+			{Name: "db errors", Group: "db", Level: LevelError, Threshold: 3, Cooldown: time.Hour},
+			{Name: "timeouts", Message: regexp.MustCompile(`time(d )?out`)},
+			{Name: "heartbeat", Group: "heartbeat", Absent: true, Window: time.Minute},
+		},
+		Notifiers: []Notifier{NotifierFunc(func(ctx context.Context, alert Alert) error {
+			notified <- alert
+			return nil
+		})},
+		Interval: time.Hour,
+	})
+	assertNoError(t, err)
+	defer alerts.Close()
+
+	next := func() Alert {
+		select {
+		case alert := <-notified:
+			return alert
+		case <-time.After(time.Second):
+			t.Fatal("no alert notified")
+			return Alert{}
+		}
+	}
+
+	tcr.Trace("db", "query").Error("deadlock")
+	tcr.Trace("db", "query").Warn("slow")
+	tcr.Trace("db", "query").Error("deadlock")
+	assertEqual(t, 0, len(alerts.Firing()))
+
+	tcr.Trace("db", "conn").Error("refused")
+	alert := next()
+	assertEqual(t, "db errors", alert.Rule)
+	assertEqual(t, AlertFiring, alert.State)
+	assertEqual(t, 3, alert.Count)
+	assertEqual(t, "3 matching ERROR entries within 1m0s", alert.Description)
+	assertEqual(t, "conn", alert.Span)
+	assertEqual(t, "refused", alert.Message)
+	assertEqual(t, []string{"db errors"}, alerts.Firing())
+
+	// deduplicated while firing
+	tcr.Trace("db", "conn").Error("refused")
+	tcr.Trace("api", "call").Info("timed out")
+	assertEqual(t, "timeouts", next().Rule)
+
+	// resolved once the entries leave the window
+	alerts.evaluate(time.Now().Add(2 * time.Minute))
+	resolved := []Alert{next(), next(), next()}
+	slices.SortFunc(resolved, func(a, b Alert) int { return strings.Compare(a.Rule, b.Rule) })
+	assertEqual(t, "db errors", resolved[0].Rule)
+	assertEqual(t, AlertResolved, resolved[0].State)
+	assertEqual(t, "heartbeat", resolved[1].Rule)
+	assertEqual(t, AlertFiring, resolved[1].State)
+	assertEqual(t, "no matching entry within 1m0s", resolved[1].Description)
+	assertEqual(t, "timeouts", resolved[2].Rule)
+	assertEqual(t, AlertResolved, resolved[2].State)
+
+	// the heartbeat resolves on the next entry
+	tcr.Trace("heartbeat", "tick").Info("alive")
+	alert = next()
+	assertEqual(t, "heartbeat", alert.Rule)
+	assertEqual(t, AlertResolved, alert.State)
+
+	// cooling down
+	for range 3 {
+		tcr.Trace("db", "conn").Error("refused")
+	}
+	assertEqual(t, 0, len(alerts.Firing()))
+
+	// alerts are recorded, without matching rules
+	assertTrue(t, len(slices.Collect(tcr.Entries(AlertGroup, "db errors"))) > 0)
+	assertEqual(t, 0, len(notified))
+}
+
+func TestAlertsSampling(t *testing.T) {
+	tcr := NewTracer()
+	tcr.SetSampling(SamplingRule{Group: "db", Sampler: FirstNSampler(1, time.Hour), IncludeErrors: true})
+	var buf bytes.Buffer
+	alerts, err := NewAlerts(tcr, AlertOptions{
+		Rules:     []AlertRule{{Name: "db errors", Group: "db", Level: LevelError, Threshold: 3}},
+		Notifiers: []Notifier{WriterNotifier(&buf)},
+		Interval:  time.Hour,
+	})
+	assertNoError(t, err)
+
+	// only the first error is stored, but every one is counted
+	for _, msg := range []string{"deadlock", "refused", "timeout"} {
+		tcr.Trace("db", "query").Error("%s", msg)
+	}
+	assertEqual(t, 1, len(slices.Collect(tcr.Entries("db", "query"))))
+	assertEqual(t, []string{"db errors"}, alerts.Firing())
+	alerts.Close()
+	assertTrue(t, strings.Contains(buf.String(), ` FIRING db errors: 3 matching ERROR entries within 1m0s, last [ERROR] db/query "timeout"`))
+}
+
+func TestNewAlertsErrors(t *testing.T) {
+	_, err := NewAlerts(NewTracer(), AlertOptions{Rules: []AlertRule{{Group: "db"}}})
+	assertEqual(t, "tracer: alert rule 0 has no name", err.Error())
+
+	_, err = NewAlerts(NewTracer(), AlertOptions{Rules: []AlertRule{{Name: "a"}, {Name: "a"}}})
+	assertEqual(t, `tracer: duplicate alert rule "a"`, err.Error())
+}
+
+func TestAlertsClose(t *testing.T) {
+	tcr := NewTracer()
+	var buf bytes.Buffer
+	alerts, err := NewAlerts(tcr, AlertOptions{
+		Rules:     []AlertRule{{Name: "errors", Level: LevelError}},
+		Notifiers: []Notifier{WriterNotifier(&buf)},
+	})
+	assertNoError(t, err)
+
+	tcr.Trace("api", "call").Error("boom")
+	alerts.Close()
+
+	// pending notifications are delivered, later entries ignored
+	tcr.Trace("api", "call").Error("boom again")
+	line := buf.String()
+	assertTrue(t, strings.HasSuffix(line, ` FIRING errors: 1 matching ERROR entries within 1m0s, last [ERROR] api/call "boom"`+"\n"))
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assertEqual(t, "application/json", r.Header.Get("Content-Type"))
+		assertNoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if got.Rule == "fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	n := WebhookNotifier(srv.URL, nil)
+	now := time.Now().UTC().Truncate(time.Second)
+	assertNoError(t, n.Notify(context.Background(), Alert{Rule: "db", State: AlertFiring, Time: now}))
+	assertEqual(t, Alert{Rule: "db", State: AlertFiring, Time: now}, got)
+
+	err := n.Notify(context.Background(), Alert{Rule: "fail"})
+	assertEqual(t, "tracer: webhook responded with 502 Bad Gateway", err.Error())
+}
@@ -0,0 +1,176 @@
+package tracer
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// Role is the level of access granted to the caller of an HTTP handler.
+type Role int
+
+const (
+	RoleNone   Role = iota
+	RoleViewer      // may view tracer data
+	RoleAdmin       // may also change the tracer, e.g. Enable, Disable or clear
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Name string
+	Role Role
+}
+
+// Authenticator identifies the caller of a request. It returns false if the
+// request carries no valid credentials.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, bool)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface.
+type AuthenticatorFunc func(r *http.Request) (Principal, bool)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (Principal, bool) {
+	return f(r)
+}
+
+// BasicUser is a user of BasicAuth.
+type BasicUser struct {
+	Password string
+	Role     Role
+}
+
+// BasicAuth authenticates requests with HTTP basic auth against users, keyed
+// by user name.
+func BasicAuth(users map[string]BasicUser) Authenticator {
+	return basicAuth(users)
+}
+
+type basicAuth map[string]BasicUser
+
+func (a basicAuth) Authenticate(r *http.Request) (Principal, bool) {
+	name, password, ok := r.BasicAuth()
+	if !ok {
+		return Principal{}, false
+	}
+	user, ok := a[name]
+	if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(user.Password)) != 1 {
+		return Principal{}, false
+	}
+	return Principal{Name: name, Role: user.Role}, true
+}
+
+func (a basicAuth) challenge() string {
+	return `Basic realm="tracelog"`
+}
+
+// BearerToken authenticates requests carrying token in an
+// "Authorization: Bearer" header, granting them role.
+func BearerToken(token string, role Role) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (Principal, bool) {
+		auth := r.Header.Get("Authorization")
+		scheme, value, ok := strings.Cut(auth, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return Principal{}, false
+		}
+		if subtle.ConstantTimeCompare([]byte(value), []byte(token)) != 1 {
+			return Principal{}, false
+		}
+		return Principal{Name: "bearer", Role: role}, true
+	})
+}
+
+// AuthFunc authenticates requests for which fn returns true, granting them
+// role. It lets callers plug in their own session or network checks.
+func AuthFunc(fn func(r *http.Request) bool, role Role) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (Principal, bool) {
+		if !fn(r) {
+			return Principal{}, false
+		}
+		return Principal{Name: "func", Role: role}, true
+	})
+}
+
+// AnyOf authenticates requests with the first of auths which accepts them.
+func AnyOf(auths ...Authenticator) Authenticator {
+	return anyOf(auths)
+}
+
+type anyOf []Authenticator
+
+func (a anyOf) Authenticate(r *http.Request) (Principal, bool) {
+	for _, auth := range a {
+		if p, ok := auth.Authenticate(r); ok {
+			return p, true
+		}
+	}
+	return Principal{}, false
+}
+
+func (a anyOf) challenge() string {
+	for _, auth := range a {
+		if c, ok := auth.(challenger); ok {
+			return c.challenge()
+		}
+	}
+	return ""
+}
+
+// challenger is implemented by authenticators which can tell the client how
+// to authenticate, through the WWW-Authenticate header.
+type challenger interface {
+	challenge() string
+}
+
+// RequireAuth wraps h so that only callers accepted by auth may reach it.
+// Viewers may only make GET, HEAD and OPTIONS requests; other methods, which
+// the handlers of this package use for mutating actions, require RoleAdmin.
+// The caller is available to h through PrincipalFromContext.
+func RequireAuth(h http.Handler, auth Authenticator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := auth.Authenticate(r)
+		if !ok || p.Role == RoleNone {
+			if c, ok := auth.(challenger); ok && c.challenge() != "" {
+				w.Header().Set("WWW-Authenticate", c.challenge())
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if requiredRole(r) > p.Role {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
+
+// PrincipalFromContext returns the caller authenticated by RequireAuth.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+type principalKey struct{}
+
+func requiredRole(r *http.Request) Role {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return RoleViewer
+	default:
+		return RoleAdmin
+	}
+}
@@ -0,0 +1,61 @@
+package tracer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAuth(t *testing.T) {
+	var seen Principal
+	h := RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = PrincipalFromContext(r.Context())
+	}), AnyOf(
+		BasicAuth(map[string]BasicUser{
+			"ops":  {Password: "ops-pw", Role: RoleAdmin},
+			"dev":  {Password: "dev-pw", Role: RoleViewer},
+			"nope": {Password: "nope-pw", Role: RoleNone},
+		}),
+		BearerToken("s3cret", RoleViewer),
+		AuthFunc(func(r *http.Request) bool { return r.Header.Get("X-Internal") == "yes" }, RoleAdmin),
+	))
+
+	serve := func(method string, setup func(r *http.Request)) int {
+		r := httptest.NewRequest(method, "/", nil)
+		setup(r)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		assertEqual(t, http.StatusUnauthorized, rec.Code)
+		assertEqual(t, `Basic realm="tracelog"`, rec.Header().Get("WWW-Authenticate"))
+
+		assertEqual(t, http.StatusUnauthorized, serve("GET", func(r *http.Request) { r.SetBasicAuth("ops", "wrong") }))
+		assertEqual(t, http.StatusUnauthorized, serve("GET", func(r *http.Request) { r.SetBasicAuth("nope", "nope-pw") }))
+		assertEqual(t, http.StatusUnauthorized, serve("GET", func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") }))
+	})
+
+	t.Run("basic", func(t *testing.T) {
+		assertEqual(t, http.StatusOK, serve("GET", func(r *http.Request) { r.SetBasicAuth("dev", "dev-pw") }))
+		assertEqual(t, Principal{Name: "dev", Role: RoleViewer}, seen)
+		assertEqual(t, http.StatusForbidden, serve("POST", func(r *http.Request) { r.SetBasicAuth("dev", "dev-pw") }))
+		assertEqual(t, http.StatusOK, serve("POST", func(r *http.Request) { r.SetBasicAuth("ops", "ops-pw") }))
+		assertEqual(t, Principal{Name: "ops", Role: RoleAdmin}, seen)
+	})
+
+	t.Run("bearer", func(t *testing.T) {
+		assertEqual(t, http.StatusOK, serve("GET", func(r *http.Request) { r.Header.Set("Authorization", "bearer s3cret") }))
+		assertEqual(t, RoleViewer, seen.Role)
+		assertEqual(t, http.StatusForbidden, serve("DELETE", func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cret") }))
+	})
+
+	t.Run("func", func(t *testing.T) {
+		assertEqual(t, http.StatusOK, serve("POST", func(r *http.Request) { r.Header.Set("X-Internal", "yes") }))
+		assertEqual(t, "admin", seen.Role.String())
+	})
+}
@@ -0,0 +1,138 @@
+package tracer
+
+import (
+	"fmt"
+	"time"
+)
+
+// BurstOptions configures burst suppression, see Tracer.SetBurstSuppression.
+type BurstOptions struct {
+	// Rate is the number of new entries per second above which a span is
+	// in a burst. Repeats of an existing entry are not counted.
+	Rate float64
+
+	// Window is the period over which the rate is measured, defaults to a
+	// second. A burst ends after a window below the rate.
+	Window time.Duration
+
+	// First and Last are the numbers of entries kept at the start and end
+	// of a burst, around the summary entry. They default to 3, and are
+	// reduced to fit the message limit of spans.
+	First, Last int
+}
+
+// burstState is the burst detection state of a span.
+type burstState struct {
+	windowStart time.Time
+	n           int // new entries in the current window
+
+	active     bool
+	start      time.Time
+	kept       int // entries appended since the burst started
+	suppressed int
+}
+
+func (t *tracer) SetBurstSuppression(opts *BurstOptions) {
+	if opts != nil {
+		o := *opts
+		if o.Window <= 0 {
+			o.Window = time.Second
+		}
+		if o.First <= 0 {
+			o.First = 3
+		}
+		if o.Last <= 0 {
+			o.Last = 3
+		}
+		opts = &o
+	}
+	t.burstOptions.Store(opts)
+}
+
+// suppressBurst is called before a new entry is appended to the entries s of
+// a span. When the span is in a burst, it makes room for the entry by
+// folding the oldest of the last entries of the burst into a summary entry,
+// and returns the updated entries. The caller must hold t.mu.
+func (t *tracer) suppressBurst(group, span string, s []logEntry, now time.Time) []logEntry {
+	opts := t.burstOptions.Load()
+	if opts == nil || opts.Rate <= 0 || t.numMessages < 1 {
+		return s
+	}
+
+	if t.bursts[group] == nil {
+		t.bursts[group] = make(map[string]*burstState)
+	}
+	st := t.bursts[group][span]
+	if st == nil {
+		st = &burstState{windowStart: now}
+		t.bursts[group][span] = st
+	}
+
+	limit := int(opts.Rate * opts.Window.Seconds())
+	if now.Sub(st.windowStart) >= opts.Window {
+		// over if the last window was calm, or a whole window passed empty
+		if st.active && (st.n <= limit || now.Sub(st.windowStart) >= 2*opts.Window) {
+			// the burst is over, its summary becomes a regular entry
+			if i := summaryIndex(s); i >= 0 {
+				s[i].summary = false
+			}
+			st.active = false
+		}
+		st.windowStart = now
+		st.n = 0
+	}
+	st.n++
+	if !st.active {
+		if st.n <= limit {
+			return s
+		}
+		*st = burstState{windowStart: st.windowStart, n: st.n, active: true, start: now}
+	}
+	st.kept++
+
+	first := min(opts.First, (t.numMessages-1)/2)
+	last := min(opts.Last, t.numMessages-1-first)
+
+	i := summaryIndex(s)
+	if i < 0 {
+		// entries of the burst held before the new one
+		held := min(st.kept-1, len(s))
+		if held < first+last || held == 0 {
+			return s
+		}
+		// the entry after the first ones becomes the summary
+		i = max(len(s)-held+first, 0)
+		st.kept--
+		st.suppressed++
+		t.stats.suppressed++
+		s[i] = logEntry{
+			group:   group,
+			span:    span,
+			level:   LevelWarn,
+			time:    s[i].time,
+			count:   1,
+			summary: true,
+			tracer:  t,
+		}
+	} else if len(s)-i-1 >= last && i+1 < len(s) {
+		// fold the oldest of the last entries into the summary
+		s[i].time = s[i+1].time
+		s = append(s[:i+1], s[i+2:]...)
+		st.kept--
+		st.suppressed++
+		t.stats.suppressed++
+	}
+	s[i].message = fmt.Sprintf("suppressed %d messages over %s", st.suppressed, s[i].time.Sub(st.start).Round(time.Millisecond))
+	return s
+}
+
+// summaryIndex returns the index of the summary entry of the current burst
+// in s, or -1.
+func summaryIndex(s []logEntry) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i].summary {
+			return i
+		}
+	}
+	return -1
+}
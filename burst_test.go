@@ -0,0 +1,76 @@
+package tracer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBurstSuppression(t *testing.T) {
+	tcr := NewTracerWithSizes(2, 2, 20)
+	tcr.SetBurstSuppression(&BurstOptions{Rate: 200, Window: 50 * time.Millisecond})
+
+	messages := func() []string {
+		var out []string
+		for entry := range tcr.Entries("api", "rpc") {
+			out = append(out, entry.Message())
+		}
+		slices.Sort(out)
+		return out
+	}
+
+	l := tcr.Trace("api", "rpc")
+	for i := range 50 {
+		l.Info("m%02d", i)
+	}
+
+	got := messages()
+	assertEqual(t, 17, len(got))
+	want := []string{"m00", "m01", "m02", "m03", "m04", "m05", "m06", "m07", "m08", "m09", "m10", "m11", "m12", "m47", "m48", "m49"}
+	assertEqual(t, want, got[:16])
+	assertTrue(t, strings.HasPrefix(got[16], "suppressed 34 messages over "))
+	assertEqual(t, uint64(34), tcr.Stats().Suppressed)
+
+	// repeats do not count towards the rate
+	for range 20 {
+		tcr.Trace("api", "other").Info("same")
+	}
+	assertEqual(t, uint32(20), slices.Collect(tcr.Entries("api", "other"))[0].Count())
+
+	// the burst ends after a calm window, and its summary is kept
+	time.Sleep(110 * time.Millisecond)
+	for i := range 5 {
+		l.Info("calm %d", i)
+	}
+	got = messages()
+	assertEqual(t, 20, len(got))
+	assertEqual(t, "calm 0", got[0])
+	assertEqual(t, uint64(34), tcr.Stats().Suppressed)
+
+	tcr.SetBurstSuppression(nil)
+	for i := range 50 {
+		l.Info("n%02d", i)
+	}
+	assertEqual(t, uint64(34), tcr.Stats().Suppressed)
+	assertFalse(t, slices.ContainsFunc(messages(), func(m string) bool { return strings.HasPrefix(m, "suppressed") }))
+}
+
+func TestBurstSuppressionSmallSpans(t *testing.T) {
+	tcr := NewTracerWithSizes(1, 1, 3)
+	tcr.SetBurstSuppression(&BurstOptions{Rate: 1, Window: time.Second})
+
+	for i := range 10 {
+		tcr.Trace("g", "s").Info("m%d", i)
+	}
+
+	// one first entry, the summary and one last entry fit
+	var got []string
+	for entry := range tcr.Entries("g", "s") {
+		got = append(got, entry.Message())
+	}
+	slices.Sort(got)
+	assertEqual(t, 3, len(got))
+	assertEqual(t, []string{"m1", "m9"}, got[:2])
+	assertTrue(t, strings.HasPrefix(got[2], "suppressed 7 messages over "))
+}
@@ -0,0 +1,97 @@
+package tracer
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// WriteChromeTrace writes the tracer contents in the Chrome Trace Event JSON
+// format, which can be loaded into Perfetto or chrome://tracing.
+//
+// Each group becomes a process and each of its spans a thread. A span is
+// rendered as a duration event from its oldest to its most recent entry, or
+// as an instant event when all its entries share the same time. Entries
+// become instant events on the span thread, with their level and count as
+// args.
+func WriteChromeTrace(w io.Writer, t Tracer) error {
+	var events []chromeEvent
+	for pid, g := range collectGroups(t) {
+		pid++ // pid 0 is ignored by some viewers
+		events = append(events, chromeEvent{
+			Name:  "process_name",
+			Phase: "M",
+			PID:   pid,
+			Args:  map[string]any{"name": g.name},
+		})
+
+		for tid, s := range g.spans {
+			tid++
+			events = append(events, chromeEvent{
+				Name:  "thread_name",
+				Phase: "M",
+				PID:   pid,
+				TID:   tid,
+				Args:  map[string]any{"name": s.name},
+			})
+
+			span := chromeEvent{
+				Name:      s.name,
+				Category:  g.name,
+				Phase:     "i",
+				Scope:     "t",
+				Timestamp: chromeTime(s.start()),
+				PID:       pid,
+				TID:       tid,
+				Args:      map[string]any{"entries": len(s.entries)},
+			}
+			if dur := s.end().Sub(s.start()); dur > 0 {
+				span.Phase = "X"
+				span.Scope = ""
+				span.Duration = ptr(float64(dur) / float64(time.Microsecond))
+			}
+			events = append(events, span)
+
+			for _, entry := range s.entries {
+				events = append(events, chromeEvent{
+					Name:      entry.Message(),
+					Category:  entry.Level(),
+					Phase:     "i",
+					Scope:     "t",
+					Timestamp: chromeTime(entry.Time()),
+					PID:       pid,
+					TID:       tid,
+					Args:      map[string]any{"level": entry.Level(), "count": entry.Count()},
+				})
+			}
+		}
+	}
+
+	return json.NewEncoder(w).Encode(chromeTrace{
+		TraceEvents:     events,
+		DisplayTimeUnit: "ms",
+	})
+}
+
+type chromeTrace struct {
+	TraceEvents     []chromeEvent `json:"traceEvents"`
+	DisplayTimeUnit string        `json:"displayTimeUnit"`
+}
+
+type chromeEvent struct {
+	Name      string         `json:"name"`
+	Category  string         `json:"cat,omitempty"`
+	Phase     string         `json:"ph"`
+	Scope     string         `json:"s,omitempty"`
+	Timestamp float64        `json:"ts"`
+	Duration  *float64       `json:"dur,omitempty"`
+	PID       int            `json:"pid"`
+	TID       int            `json:"tid"`
+	Args      map[string]any `json:"args,omitempty"`
+}
+
+// chromeTime returns t in microseconds since the unix epoch, the trace event
+// time unit.
+func chromeTime(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Microsecond)
+}
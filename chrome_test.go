@@ -0,0 +1,54 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWriteChromeTrace(t *testing.T) {
+	tcr := NewTracer()
+	tcr.Trace("api", "rpc").Info("getUser")
+	time.Sleep(2 * time.Millisecond)
+	tcr.Trace("api", "rpc").Warn("slow")
+	tcr.Trace("api", "rpc").Warn("slow")
+	tcr.Trace("server", "run").Info("boot")
+
+	var buf bytes.Buffer
+	assertNoError(t, WriteChromeTrace(&buf, tcr))
+
+	var out chromeTrace
+	assertNoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assertEqual(t, "ms", out.DisplayTimeUnit)
+
+	var processes []any
+	byName := map[string]chromeEvent{}
+	for _, ev := range out.TraceEvents {
+		byName[ev.Name+"/"+ev.Phase] = ev
+		if ev.Name == "process_name" {
+			processes = append(processes, ev.Args["name"])
+		}
+	}
+
+	// server was logged last, so it is the first process
+	assertEqual(t, []any{"server", "api"}, processes)
+
+	run := byName["run/i"]
+	assertEqual(t, "server", run.Category)
+	assertEqual(t, 1, run.PID)
+	assertTrue(t, run.Duration == nil)
+
+	rpc := byName["rpc/X"]
+	assertEqual(t, "api", rpc.Category)
+	assertEqual(t, 2, rpc.PID)
+	assertEqual(t, 1, rpc.TID)
+	assertTrue(t, *rpc.Duration >= 2000)
+
+	slow := byName["slow/i"]
+	assertEqual(t, "WARN", slow.Args["level"])
+	assertEqual(t, float64(2), slow.Args["count"])
+	assertEqual(t, "t", slow.Scope)
+	assertTrue(t, math.Abs(rpc.Timestamp+*rpc.Duration-slow.Timestamp) < 1)
+}
@@ -0,0 +1,112 @@
+package tracer
+
+import (
+	"strings"
+	"time"
+)
+
+// Query selects entries for DeleteWhere. An entry matches when it matches
+// every non-zero field.
+type Query struct {
+	// Group and Span match group and span names by prefix, like the ToMap
+	// filters.
+	Group string
+	Span  string
+
+	// Level matches entries of exactly this level.
+	Level string
+
+	// Message matches entries whose message contains it.
+	Message string
+
+	// Before matches entries last logged before it.
+	Before time.Time
+
+	// Match, if set, is called for entries matching the other fields. It
+	// runs under the tracer lock and must not call the tracer.
+	Match func(LogEntry) bool
+}
+
+func (q Query) matches(entry logEntry) bool {
+	if q.Level != "" && entry.level != q.Level {
+		return false
+	}
+	if q.Message != "" && !strings.Contains(entry.message, q.Message) {
+		return false
+	}
+	if !q.Before.IsZero() && !entry.time.Before(q.Before) {
+		return false
+	}
+	return q.Match == nil || q.Match(entry)
+}
+
+func (t *tracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.logs = make(map[string]map[string][]logEntry)
+	t.groupTS = make(map[string]time.Time)
+	t.spanTS = make(map[string]map[string]time.Time)
+	t.stats = newTracerStats()
+	t.bursts = make(map[string]map[string]*burstState)
+	t.droppedDisabled.Store(0)
+	t.droppedLevel.Store(0)
+	t.droppedSampled.Store(0)
+}
+
+func (t *tracer) DeleteGroup(group string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.logs[group]; !ok {
+		return false
+	}
+	t.deleteGroup(group)
+	return true
+}
+
+func (t *tracer) DeleteSpan(group, span string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.logs[group][span]; !ok {
+		return false
+	}
+	t.deleteSpan(group, span)
+	return true
+}
+
+func (t *tracer) DeleteWhere(q Query) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	deleted := 0
+	for group, spans := range t.logs {
+		if q.Group != "" && !strings.HasPrefix(group, q.Group) {
+			continue
+		}
+		for span, entries := range spans {
+			if q.Span != "" && !strings.HasPrefix(span, q.Span) {
+				continue
+			}
+
+			kept := entries[:0]
+			for _, entry := range entries {
+				if q.matches(entry) {
+					deleted++
+				} else {
+					kept = append(kept, entry)
+				}
+			}
+			// clear the tail, so removed entries can be collected
+			clear(entries[len(kept):])
+
+			if len(kept) == 0 {
+				t.deleteSpan(group, span)
+			} else {
+				spans[span] = kept
+			}
+		}
+	}
+	return deleted
+}
@@ -0,0 +1,131 @@
+package tracer
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTracerDelete(t *testing.T) {
+	tcr := NewTracer()
+	rawTcr := tcr.(*tracer)
+
+	setup := func() {
+		tcr.Reset()
+		tcr.Trace("api", "rpc").Info("getUser")
+		tcr.Trace("api", "rpc").Error("boom")
+		tcr.Trace("api", "db").Info("getX")
+		tcr.Trace("server", "run").Info("boot")
+	}
+
+	t.Run("reset", func(t *testing.T) {
+		setup()
+		tcr.Disable()
+		tcr.Trace("api", "rpc").Info("dropped")
+		tcr.Enable()
+		tcr.Pin("server")
+
+		tcr.Reset()
+		assertEqual(t, 0, len(rawTcr.logs))
+		assertEqual(t, 0, len(rawTcr.groupTS))
+		assertEqual(t, 0, len(rawTcr.spanTS))
+
+		st := tcr.Stats()
+		assertEqual(t, uint64(0), st.Logged.Total())
+		assertEqual(t, uint64(0), st.DroppedDisabled)
+		assertEqual(t, 0, len(st.PerGroup))
+		assertEqual(t, []string{"server"}, st.Pinned)
+		tcr.Unpin("server")
+	})
+
+	t.Run("delete group", func(t *testing.T) {
+		setup()
+		assertTrue(t, tcr.DeleteGroup("api"))
+		assertFalse(t, tcr.DeleteGroup("api"))
+		assertEqual(t, []string{"server"}, slices.Collect(tcr.Groups()))
+		assertEqual(t, 0, len(rawTcr.spanTS["api"]))
+		_, ok := tcr.Stats().PerGroup["api"]
+		assertFalse(t, ok)
+	})
+
+	t.Run("delete span", func(t *testing.T) {
+		setup()
+		assertTrue(t, tcr.DeleteSpan("api", "rpc"))
+		assertFalse(t, tcr.DeleteSpan("api", "rpc"))
+		assertFalse(t, tcr.DeleteSpan("missing", "rpc"))
+		assertEqual(t, []string{"db"}, slices.Collect(tcr.Spans("api")))
+
+		// the last span takes its group along
+		assertTrue(t, tcr.DeleteSpan("api", "db"))
+		assertEqual(t, []string{"server"}, slices.Collect(tcr.Groups()))
+		_, ok := rawTcr.groupTS["api"]
+		assertFalse(t, ok)
+	})
+
+	t.Run("delete where", func(t *testing.T) {
+		setup()
+		assertEqual(t, 1, tcr.DeleteWhere(Query{Level: LevelError}))
+		assertEqual(t, 1, len(slices.Collect(tcr.Entries("api", "rpc"))))
+
+		assertEqual(t, 0, tcr.DeleteWhere(Query{Group: "api", Message: "boot"}))
+		assertEqual(t, 2, tcr.DeleteWhere(Query{Message: "get"}))
+		assertEqual(t, []string{"server"}, slices.Collect(tcr.Groups()))
+
+		setup()
+		assertEqual(t, 2, tcr.DeleteWhere(Query{Group: "a", Span: "r"}))
+		assertEqual(t, []string{"db"}, slices.Collect(tcr.Spans("api")))
+
+		assertEqual(t, 0, tcr.DeleteWhere(Query{Before: time.Now().Add(-time.Minute)}))
+		assertEqual(t, 2, tcr.DeleteWhere(Query{Before: time.Now().Add(time.Minute)}))
+		assertEqual(t, 0, len(rawTcr.logs))
+
+		setup()
+		n := tcr.DeleteWhere(Query{Match: func(e LogEntry) bool { return e.Span() == "db" }})
+		assertEqual(t, 1, n)
+	})
+}
+
+func TestTracerDeleteConcurrency(t *testing.T) {
+	tcr := NewTracerWithSizes(4, 4, 4)
+	rawTcr := tcr.(*tracer)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(routineID int) {
+			defer wg.Done()
+			for n := 0; n < 500; n++ {
+				group := fmt.Sprintf("group-%d", n%6)
+				span := fmt.Sprintf("span-%d", n%5)
+				tcr.Trace(group, span).Info("message %d from %d", n, routineID)
+
+				switch n % 50 {
+				case 10:
+					tcr.DeleteGroup(group)
+				case 20:
+					tcr.DeleteSpan(group, span)
+				case 30:
+					tcr.DeleteWhere(Query{Message: "from 1"})
+				case 49:
+					tcr.Reset()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for group, spans := range rawTcr.logs {
+		_, ok := rawTcr.groupTS[group]
+		assertTrue(t, ok)
+		assertEqual(t, len(spans), len(rawTcr.spanTS[group]))
+		for span, entries := range spans {
+			_, ok := rawTcr.spanTS[group][span]
+			assertTrue(t, ok)
+			assertTrue(t, len(entries) > 0)
+		}
+	}
+	assertEqual(t, len(rawTcr.logs), len(rawTcr.groupTS))
+	assertEqual(t, len(rawTcr.logs), len(rawTcr.spanTS))
+}
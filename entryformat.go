@@ -0,0 +1,143 @@
+package tracer
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Attr is a key/value attribute attached to an entry, see Logger.WithAttrs.
+type Attr struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// EntryFormat customizes the rendering of LogEntry.FormattedMessage, which
+// is also what ToMap and WriteJSON output.
+type EntryFormat struct {
+	// TimeLayout is the layout of absolute times, used when the exact time
+	// is requested and for relative times older than a day. Defaults to
+	// time.RFC822.
+	TimeLayout string
+
+	// Format renders an entry. Defaults to "<when> - [<level>] <message>",
+	// followed by " [x<count>]" for repeated messages and " [sampled 1/<n>]"
+	// for sampled ones.
+	Format func(EntryData) string
+
+	// Humanizer renders relative times, for TimeAgo and the When and Ago
+	// fields of EntryData. Defaults to the zero RelativeTime.
+	Humanizer Humanizer
+}
+
+// EntryData is the data available to an EntryFormat.
+type EntryData struct {
+	Level   string
+	Group   string
+	Span    string
+	Message string
+	Count   uint32
+	Attrs   []Attr
+
+	// Sampled is the number of messages the last occurrence of the entry
+	// stands for when sampling suppressed some, and 1 otherwise.
+	Sampled uint32
+
+	// Time is the time of the entry in the requested timezone.
+	Time time.Time
+
+	// When is the time of the entry rendered with the format TimeLayout
+	// when the exact time is requested, and relative to now otherwise.
+	When string
+
+	// Ago is the time of the entry relative to now.
+	Ago string
+}
+
+// Attr returns the value of the last attribute with the given key, or an
+// empty string. In templates: {{.Attr "status"}}.
+func (d EntryData) Attr(key string) string {
+	for i := len(d.Attrs) - 1; i >= 0; i-- {
+		if d.Attrs[i].Key == key {
+			return d.Attrs[i].Value
+		}
+	}
+	return ""
+}
+
+// TemplateFormat returns an EntryFormat.Format func executing a text/template
+// with the EntryData of each entry, such as:
+//
+//	[{{.Level}}] {{.Time.Format "2006-01-02T15:04:05Z07:00"}} {{.Group}}/{{.Span}} {{.Message}}
+//
+// If the template fails to execute, the entry is rendered with the default
+// format instead.
+func TemplateFormat(text string) (func(EntryData) string, error) {
+	tmpl, err := template.New("entry").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("tracer: invalid entry template: %w", err)
+	}
+	return func(d EntryData) string {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, d); err != nil {
+			return defaultEntryFormat(d)
+		}
+		return b.String()
+	}, nil
+}
+
+func (t *tracer) SetEntryFormat(format *EntryFormat) {
+	t.entryFormat.Store(format)
+}
+
+func (f *EntryFormat) timeLayout() string {
+	if f == nil || f.TimeLayout == "" {
+		return time.RFC822
+	}
+	return f.TimeLayout
+}
+
+func (f *EntryFormat) humanizer() Humanizer {
+	if f == nil || f.Humanizer == nil {
+		return RelativeTime{}
+	}
+	return f.Humanizer
+}
+
+// render formats entry with f, which may be nil for the default format.
+func (f *EntryFormat) render(entry logEntry, loc *time.Location, withExactTime bool) string {
+	layout := f.timeLayout()
+	d := EntryData{
+		Level:   entry.level,
+		Group:   entry.group,
+		Span:    entry.span,
+		Message: entry.message,
+		Count:   entry.count,
+		Attrs:   entry.attrs,
+		Sampled: max(entry.sampled, 1),
+		Time:    entry.time.In(loc),
+	}
+	d.Ago = f.humanizer().Humanize(d.Time, time.Now(), layout)
+	if withExactTime {
+		d.When = d.Time.Format(layout)
+	} else {
+		d.When = d.Ago
+	}
+
+	if f == nil || f.Format == nil {
+		return defaultEntryFormat(d)
+	}
+	return f.Format(d)
+}
+
+func defaultEntryFormat(d EntryData) string {
+	out := fmt.Sprintf("%s - [%s] %s", d.When, d.Level, d.Message)
+	if d.Count > 1 {
+		out = fmt.Sprintf("%s [x%d]", out, d.Count)
+	}
+	if d.Sampled > 1 {
+		out = fmt.Sprintf("%s [sampled 1/%d]", out, d.Sampled)
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package tracer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntryFormat(t *testing.T) {
+	tcr := NewTracer()
+	trace := tcr.Trace("api", "rpc").WithAttrs(Attr{"method", "GET"})
+	trace.WithAttrs(Attr{"status", "500"}).Error("boom")
+	trace.WithAttrs(Attr{"status", "502"}).Error("boom")
+
+	entry := func() LogEntry {
+		for e := range tcr.Entries("api", "rpc") {
+			return e
+		}
+		return nil
+	}
+
+	t.Run("default", func(t *testing.T) {
+		assertEqual(t, "0s ago - [ERROR] boom [x2]", entry().FormattedMessage("UTC"))
+	})
+
+	t.Run("attrs", func(t *testing.T) {
+		// deduplicated entries keep the attrs of the latest occurrence
+		assertEqual(t, []Attr{{"method", "GET"}, {"status", "502"}}, entry().Attrs())
+
+		trace.Span("db").Info("getX")
+		for e := range tcr.Entries("api", "db") {
+			assertEqual(t, []Attr{{"method", "GET"}}, e.Attrs())
+		}
+	})
+
+	t.Run("time layout", func(t *testing.T) {
+		tcr.SetEntryFormat(&EntryFormat{TimeLayout: time.RFC3339})
+		defer tcr.SetEntryFormat(nil)
+
+		msg := entry().FormattedMessage("America/New_York", true)
+		when, _, _ := strings.Cut(msg, " - ")
+		ts, err := time.Parse(time.RFC3339, when)
+		assertNoError(t, err)
+		assertTrue(t, ts.Equal(entry().Time().Truncate(time.Second)))
+		assertTrue(t, strings.HasSuffix(msg, " - [ERROR] boom [x2]"))
+	})
+
+	t.Run("func", func(t *testing.T) {
+		tcr.SetEntryFormat(&EntryFormat{Format: func(d EntryData) string {
+			return d.Level + " " + d.Group + "/" + d.Span + " " + d.Message + " " + d.When
+		}})
+		defer tcr.SetEntryFormat(nil)
+
+		assertEqual(t, "ERROR api/rpc boom 0s ago", entry().FormattedMessage("UTC"))
+
+		_, jsonOut := tcr.ToMap("UTC", false, "api", "rpc")
+		assertEqual(t, `{"api":{"rpc":["ERROR api/rpc boom 0s ago"]}}`, string(jsonOut))
+	})
+
+	t.Run("template", func(t *testing.T) {
+		format, err := TemplateFormat(`[{{.Level}}] {{.Time.Format "2006"}} {{.Message}} status={{.Attr "status"}} x{{.Count}}`)
+		assertNoError(t, err)
+		tcr.SetEntryFormat(&EntryFormat{Format: format})
+		defer tcr.SetEntryFormat(nil)
+
+		year := entry().Time().Format("2006")
+		assertEqual(t, "[ERROR] "+year+" boom status=502 x2", entry().FormattedMessage("UTC", true))
+	})
+
+	t.Run("template errors", func(t *testing.T) {
+		_, err := TemplateFormat(`{{.Level`)
+		assertTrue(t, err != nil)
+
+		format, err := TemplateFormat(`{{.Missing}}`)
+		assertNoError(t, err)
+		tcr.SetEntryFormat(&EntryFormat{Format: format})
+		defer tcr.SetEntryFormat(nil)
+
+		assertEqual(t, "0s ago - [ERROR] boom [x2]", entry().FormattedMessage("UTC"))
+	})
+}
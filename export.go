@@ -0,0 +1,82 @@
+package tracer
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+	"time"
+)
+
+// exportGroup and exportSpan hold the tracer contents in the shape shared by
+// the trace exporters: groups and spans in recency order, and entries in
+// chronological order.
+type exportGroup struct {
+	name  string
+	spans []exportSpan
+}
+
+type exportSpan struct {
+	name    string
+	entries []LogEntry
+}
+
+// start returns the time of the oldest entry of the span.
+func (s exportSpan) start() time.Time {
+	return s.entries[0].Time()
+}
+
+// end returns the time of the most recent entry of the span.
+func (s exportSpan) end() time.Time {
+	return s.entries[len(s.entries)-1].Time()
+}
+
+// hasErrors reports whether the span holds an ERROR entry.
+func (s exportSpan) hasErrors() bool {
+	for _, entry := range s.entries {
+		if entry.Level() == LevelError {
+			return true
+		}
+	}
+	return false
+}
+
+// collectGroups reads a consistent snapshot of t through All.
+func collectGroups(t Tracer) []exportGroup {
+	var groups []exportGroup
+	for entry := range t.All() {
+		if len(groups) == 0 || groups[len(groups)-1].name != entry.Group() {
+			groups = append(groups, exportGroup{name: entry.Group()})
+		}
+		g := &groups[len(groups)-1]
+		if len(g.spans) == 0 || g.spans[len(g.spans)-1].name != entry.Span() {
+			g.spans = append(g.spans, exportSpan{name: entry.Span()})
+		}
+		s := &g.spans[len(g.spans)-1]
+		s.entries = append(s.entries, entry)
+	}
+
+	// All yields entries most recent first
+	for _, g := range groups {
+		for _, s := range g.spans {
+			for i, j := 0, len(s.entries)-1; i < j; i, j = i+1, j-1 {
+				s.entries[i], s.entries[j] = s.entries[j], s.entries[i]
+			}
+		}
+	}
+	return groups
+}
+
+// traceID returns a stable 128-bit trace id for a group, hex encoded.
+func traceID(group string) string {
+	h := fnv.New128a()
+	h.Write([]byte(group))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// spanID returns a stable 64-bit span id for a span of a group, hex encoded.
+func spanID(group, span string) string {
+	h := fnv.New64a()
+	h.Write([]byte(group))
+	h.Write([]byte{0})
+	h.Write([]byte(span))
+	return hex.EncodeToString(h.Sum(nil))
+}
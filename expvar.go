@@ -0,0 +1,46 @@
+package tracer
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+)
+
+// ExpvarOptions configures the data published by PublishExpvar. The fields
+// have the same meaning as the ToMap arguments.
+type ExpvarOptions struct {
+	Timezone      string
+	WithExactTime bool
+	GroupFilter   string
+	SpanFilter    string
+}
+
+// PublishExpvar publishes the tracer stats and logs under name, so they are
+// served by the standard /debug/vars endpoint. The value is computed on each
+// read. It returns an error if name is already published or the timezone is
+// invalid.
+func PublishExpvar(name string, t Tracer, opts ExpvarOptions) error {
+	if _, err := LoadLocation(opts.Timezone); err != nil {
+		return err
+	}
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("tracer: expvar %q is already published", name)
+	}
+	expvar.Publish(name, ExpvarFunc(t, opts))
+	return nil
+}
+
+// ExpvarFunc returns an expvar.Func reporting the tracer stats and logs, for
+// callers who manage their own expvar.Map.
+func ExpvarFunc(t Tracer, opts ExpvarOptions) expvar.Func {
+	return func() any {
+		_, logs := t.ToMap(opts.Timezone, opts.WithExactTime, opts.GroupFilter, opts.SpanFilter)
+		return struct {
+			Stats Stats           `json:"stats"`
+			Logs  json.RawMessage `json:"logs"`
+		}{
+			Stats: t.Stats(),
+			Logs:  logs,
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package tracer
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	tcr := NewTracer()
+	tcr.Trace("api", "rpc").Info("getUser")
+	tcr.Trace("api", "rpc").Info("getUser")
+
+	var out struct {
+		Stats Stats                          `json:"stats"`
+		Logs  map[string]map[string][]string `json:"logs"`
+	}
+	assertNoError(t, json.Unmarshal([]byte(ExpvarFunc(tcr, ExpvarOptions{Timezone: "UTC"}).String()), &out))
+
+	assertEqual(t, uint64(2), out.Stats.Logged.Info)
+	assertEqual(t, uint64(1), out.Stats.Deduplicated)
+	assertEqual(t, 1, len(out.Logs["api"]["rpc"]))
+	assertEqual(t, "0s ago - [INFO] getUser [x2]", out.Logs["api"]["rpc"][0])
+
+	// expvar names are process global, so find one free in this run
+	name := "tracer_test"
+	for i := 1; expvar.Get(name) != nil; i++ {
+		name = fmt.Sprintf("tracer_test_%d", i)
+	}
+	assertNoError(t, PublishExpvar(name, tcr, ExpvarOptions{Timezone: "UTC"}))
+	assertTrue(t, PublishExpvar(name, tcr, ExpvarOptions{}) != nil)
+	assertEqual(t, ExpvarFunc(tcr, ExpvarOptions{Timezone: "UTC"}).String(), expvar.Get(name).String())
+}
@@ -0,0 +1,168 @@
+package tracer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Formatter renders a batch of entries, such as a dump of the tracer, to w.
+type Formatter interface {
+	Format(w io.Writer, entries []LogEntry) error
+}
+
+// FormatterFunc adapts a function to the Formatter interface.
+type FormatterFunc func(w io.Writer, entries []LogEntry) error
+
+func (f FormatterFunc) Format(w io.Writer, entries []LogEntry) error {
+	return f(w, entries)
+}
+
+// Dump renders the entries of the groups and spans matching the prefix
+// filters with f, in the same order as ToMap.
+func Dump(w io.Writer, t Tracer, f Formatter, groupFilter, spanFilter string) error {
+	var entries []LogEntry
+	for entry := range t.All() {
+		if groupFilter != "" && !strings.HasPrefix(entry.Group(), groupFilter) {
+			continue
+		}
+		if spanFilter != "" && !strings.HasPrefix(entry.Span(), spanFilter) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return f.Format(w, entries)
+}
+
+// LogfmtFormatter renders one logfmt line per entry, with the time, level,
+// group, span, count and msg keys followed by the entry attrs.
+type LogfmtFormatter struct {
+	Timezone   string // defaults to UTC, Format fails if it is invalid
+	TimeLayout string // defaults to time.RFC3339
+}
+
+func (f LogfmtFormatter) Format(w io.Writer, entries []LogEntry) error {
+	loc, layout, err := formatterTime(f.Timezone, f.TimeLayout)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, entry := range entries {
+		b.Reset()
+		b.WriteString("time=")
+		b.WriteString(logfmtValue(entry.Time().In(loc).Format(layout)))
+		b.WriteString(" level=")
+		b.WriteString(logfmtValue(entry.Level()))
+		b.WriteString(" group=")
+		b.WriteString(logfmtValue(entry.Group()))
+		b.WriteString(" span=")
+		b.WriteString(logfmtValue(entry.Span()))
+		b.WriteString(" count=")
+		b.WriteString(strconv.FormatUint(uint64(entry.Count()), 10))
+		b.WriteString(" msg=")
+		b.WriteString(logfmtValue(entry.Message()))
+		for _, attr := range entry.Attrs() {
+			b.WriteString(" ")
+			b.WriteString(logfmtValue(attr.Key))
+			b.WriteString("=")
+			b.WriteString(logfmtValue(attr.Value))
+		}
+		b.WriteString("\n")
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logfmtValue quotes v if it is empty or contains spaces, quotes, equal
+// signs or control characters.
+func logfmtValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " =\"\\") || strings.IndexFunc(v, func(r rune) bool { return r < ' ' }) >= 0 {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+// CSVFormatter renders entries as CSV rows with the time, level, group,
+// span, count and message columns.
+type CSVFormatter struct {
+	Timezone   string // defaults to UTC, Format fails if it is invalid
+	TimeLayout string // defaults to time.RFC3339
+	NoHeader   bool   // omit the header row
+}
+
+func (f CSVFormatter) Format(w io.Writer, entries []LogEntry) error {
+	loc, layout, err := formatterTime(f.Timezone, f.TimeLayout)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if !f.NoHeader {
+		cw.Write([]string{"time", "level", "group", "span", "count", "message"})
+	}
+	for _, entry := range entries {
+		cw.Write([]string{
+			entry.Time().In(loc).Format(layout),
+			entry.Level(),
+			entry.Group(),
+			entry.Span(),
+			strconv.FormatUint(uint64(entry.Count()), 10),
+			entry.Message(),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// TableFormatter renders entries as a plain-text table with aligned
+// columns, for reading in a terminal.
+type TableFormatter struct {
+	Timezone   string // defaults to UTC, Format fails if it is invalid
+	TimeLayout string // defaults to time.RFC3339
+}
+
+func (f TableFormatter) Format(w io.Writer, entries []LogEntry) error {
+	loc, layout, err := formatterTime(f.Timezone, f.TimeLayout)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tLEVEL\tGROUP\tSPAN\tCOUNT\tMESSAGE")
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			entry.Time().In(loc).Format(layout),
+			entry.Level(),
+			tableCell(entry.Group()),
+			tableCell(entry.Span()),
+			entry.Count(),
+			tableCell(entry.Message()),
+		)
+	}
+	return tw.Flush()
+}
+
+var tableCellReplacer = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+// tableCell keeps a value on a single cell of the table.
+func tableCell(v string) string {
+	return tableCellReplacer.Replace(v)
+}
+
+func formatterTime(timezone, layout string) (*time.Location, string, error) {
+	loc, err := LoadLocation(timezone)
+	if err != nil {
+		return nil, "", err
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return loc, layout, nil
+}
@@ -0,0 +1,74 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatters(t *testing.T) {
+	tcr := NewTracer()
+	tcr.Trace("api", "rpc").Info("get user")
+	time.Sleep(time.Millisecond)
+	tcr.Trace("api", "db").Warn(`say "hi"`)
+	tcr.Trace("api", "db").Warn(`say "hi"`)
+	time.Sleep(time.Millisecond)
+	tcr.Trace("server", "run").Error("boot\tfailed")
+
+	t.Run("logfmt", func(t *testing.T) {
+		var buf bytes.Buffer
+		assertNoError(t, Dump(&buf, tcr, LogfmtFormatter{}, "api", ""))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assertEqual(t, 2, len(lines))
+		assertTrue(t, strings.HasPrefix(lines[0], "time="))
+		assertTrue(t, strings.HasSuffix(lines[0], ` level=WARN group=api span=db count=2 msg="say \"hi\""`))
+		assertTrue(t, strings.HasSuffix(lines[1], ` level=INFO group=api span=rpc count=1 msg="get user"`))
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		assertNoError(t, Dump(&buf, tcr, CSVFormatter{Timezone: "America/New_York"}, "", ""))
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		assertNoError(t, err)
+		assertEqual(t, 4, len(records))
+		assertEqual(t, []string{"time", "level", "group", "span", "count", "message"}, records[0])
+		assertEqual(t, []string{"ERROR", "server", "run", "1", "boot\tfailed"}, records[1][1:])
+		assertEqual(t, []string{"WARN", "api", "db", "2", `say "hi"`}, records[2][1:])
+
+		_, err = time.Parse(time.RFC3339, records[1][0])
+		assertNoError(t, err)
+	})
+
+	t.Run("csv without header", func(t *testing.T) {
+		var buf bytes.Buffer
+		assertNoError(t, Dump(&buf, tcr, CSVFormatter{NoHeader: true}, "server", ""))
+		assertEqual(t, 1, strings.Count(buf.String(), "\n"))
+	})
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		assertNoError(t, Dump(&buf, tcr, TableFormatter{TimeLayout: time.Kitchen}, "", "r"))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assertEqual(t, 3, len(lines))
+		assertTrue(t, strings.HasPrefix(lines[0], "TIME"))
+		assertEqual(t, strings.Index(lines[0], "LEVEL"), strings.Index(lines[1], "ERROR"))
+		assertEqual(t, strings.Index(lines[0], "MESSAGE"), strings.Index(lines[1], "boot failed"))
+		assertEqual(t, strings.Index(lines[0], "MESSAGE"), strings.Index(lines[2], "get user"))
+	})
+
+	t.Run("func", func(t *testing.T) {
+		var n int
+		f := FormatterFunc(func(w io.Writer, entries []LogEntry) error {
+			n = len(entries)
+			return nil
+		})
+		assertNoError(t, Dump(io.Discard, tcr, f, "", ""))
+		assertEqual(t, 3, n)
+	})
+}
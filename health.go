@@ -0,0 +1,265 @@
+package tracer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HealthStatus is the health of a group, see HealthChecker.
+type HealthStatus int
+
+const (
+	HealthHealthy HealthStatus = iota
+	HealthDegraded
+	HealthUnhealthy
+)
+
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthHealthy:
+		return "healthy"
+	case HealthDegraded:
+		return "degraded"
+	default:
+		return "unhealthy"
+	}
+}
+
+func (s HealthStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// HealthRule counts the entries of a group recorded within a window and
+// sets the health of the group to Status when Fails reports true for that
+// count. For example, more than 5 ERROR entries in group db within a minute:
+//
+//	HealthRule{Group: "db", Level: LevelError, Window: time.Minute, Fails: MoreThan(5)}
+//
+// or no INFO entry in group heartbeat for 30s:
+//
+//	HealthRule{Group: "heartbeat", Level: LevelInfo, Window: 30 * time.Second, Fails: FewerThan(1)}
+//
+// Repeated entries count as many times as they were logged, and windowed
+// rules also count the messages dropped by sampling.
+type HealthRule struct {
+	// Name describes the rule in reports, defaults to a description of its
+	// filters.
+	Name string
+
+	// Group is the name of the group, and Span, if set, a prefix of the
+	// names of the spans whose entries are counted.
+	Group string
+	Span  string
+
+	// Level, if set, counts only the entries of exactly this level.
+	Level string
+
+	// Window counts only the entries logged within it, as observed by the
+	// HealthChecker, even if the tracer no longer holds them. Zero counts
+	// all the entries held by the tracer.
+	Window time.Duration
+
+	// Fails reports whether the rule fails for the number of entries.
+	Fails func(count int) bool
+
+	// Status is the health of the group when the rule fails, defaults to
+	// HealthUnhealthy.
+	Status HealthStatus
+}
+
+// MoreThan returns a HealthRule.Fails func failing for more than n entries.
+func MoreThan(n int) func(count int) bool {
+	return func(count int) bool { return count > n }
+}
+
+// FewerThan returns a HealthRule.Fails func failing for fewer than n
+// entries.
+func FewerThan(n int) func(count int) bool {
+	return func(count int) bool { return count < n }
+}
+
+func (r HealthRule) name() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	var b strings.Builder
+	if r.Level != "" {
+		fmt.Fprintf(&b, "%s ", r.Level)
+	}
+	fmt.Fprintf(&b, "entries in group %q", r.Group)
+	if r.Span != "" {
+		fmt.Fprintf(&b, " span %q", r.Span)
+	}
+	if r.Window > 0 {
+		fmt.Fprintf(&b, " within %s", r.Window)
+	}
+	return b.String()
+}
+
+func (r HealthRule) status() HealthStatus {
+	if r.Status == HealthHealthy {
+		return HealthUnhealthy
+	}
+	return r.Status
+}
+
+func (r HealthRule) matches(entry LogEntry) bool {
+	return entry.Group() == r.Group &&
+		strings.HasPrefix(entry.Span(), r.Span) &&
+		(r.Level == "" || entry.Level() == r.Level)
+}
+
+// count returns the number of entries held by t matching r.
+func (r HealthRule) count(t Tracer) int {
+	var count int
+	for span := range t.Spans(r.Group) {
+		if !strings.HasPrefix(span, r.Span) {
+			continue
+		}
+		for entry := range t.Entries(r.Group, span) {
+			if r.Level == "" || entry.Level() == r.Level {
+				count += int(entry.Count())
+			}
+		}
+	}
+	return count
+}
+
+// maxHealthTimes is the number of occurrences a rule keeps within its
+// window, above which its count no longer grows.
+const maxHealthTimes = 10000
+
+type healthRule struct {
+	HealthRule
+	times []time.Time // of the occurrences within the window
+}
+
+// prune drops the times which left the window. The caller must hold c.mu.
+func (r *healthRule) prune(now time.Time) {
+	i := 0
+	for i < len(r.times) && now.Sub(r.times[i]) > r.Window {
+		i++
+	}
+	r.times = r.times[i:]
+}
+
+// HealthChecker evaluates health rules over the entries of a tracer. Rules
+// with a Window count every occurrence of the entries they match as it is
+// logged, so they only count the entries logged after NewHealthChecker.
+type HealthChecker struct {
+	tracer      Tracer
+	unsubscribe func()
+
+	mu    sync.Mutex
+	rules []*healthRule
+}
+
+// NewHealthChecker starts observing the entries of t for rules, until
+// Close.
+func NewHealthChecker(t Tracer, rules []HealthRule) *HealthChecker {
+	c := &HealthChecker{tracer: t, rules: make([]*healthRule, len(rules))}
+	for i, rule := range rules {
+		c.rules[i] = &healthRule{HealthRule: rule}
+	}
+	c.unsubscribe = observeEntries(t, c.observe)
+	return c
+}
+
+// Close stops observing the entries of the tracer.
+func (c *HealthChecker) Close() {
+	c.unsubscribe()
+}
+
+// observe records the time of entry for the windowed rules matching it.
+func (c *HealthChecker) observe(entry LogEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rule := range c.rules {
+		if rule.Window <= 0 || !rule.matches(entry) {
+			continue
+		}
+		rule.times = append(rule.times, entry.Time())
+		rule.prune(entry.Time())
+		if len(rule.times) > maxHealthTimes {
+			rule.times = rule.times[1:]
+		}
+	}
+}
+
+// HealthReport is the health of the groups checked by a set of rules.
+type HealthReport struct {
+	// Status is the worst status of the groups.
+	Status HealthStatus           `json:"status"`
+	Groups map[string]GroupHealth `json:"groups"`
+}
+
+// GroupHealth is the health of a group and the rules it failed.
+type GroupHealth struct {
+	Status   HealthStatus    `json:"status"`
+	Failures []HealthFailure `json:"failures,omitempty"`
+}
+
+// HealthFailure is a failed HealthRule.
+type HealthFailure struct {
+	Rule   string       `json:"rule"`
+	Count  int          `json:"count"`
+	Status HealthStatus `json:"status"`
+}
+
+// Check evaluates the rules. Every group with a rule is reported, with the
+// worst status of its failed rules.
+func (c *HealthChecker) Check() HealthReport {
+	now := time.Now()
+	report := HealthReport{Groups: make(map[string]GroupHealth)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rule := range c.rules {
+		group := report.Groups[rule.Group]
+		var count int
+		if rule.Window > 0 {
+			rule.prune(now)
+			count = len(rule.times)
+		} else {
+			count = rule.count(c.tracer)
+		}
+		if rule.Fails != nil && rule.Fails(count) {
+			status := rule.status()
+			group.Failures = append(group.Failures, HealthFailure{
+				Rule:   rule.name(),
+				Count:  count,
+				Status: status,
+			})
+			group.Status = max(group.Status, status)
+			report.Status = max(report.Status, status)
+		}
+		report.Groups[rule.Group] = group
+	}
+	return report
+}
+
+// ServeHTTP reports the Check report as JSON, for readiness probes. It
+// responds with 503 Service Unavailable when a group is unhealthy, and 200
+// OK otherwise, including when degraded.
+func (c *HealthChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := c.Check()
+
+	status := http.StatusOK
+	if report.Status == HealthUnhealthy {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// HealthHandler returns a HealthChecker of rules over t, which is never
+// closed, as an http.Handler.
+func HealthHandler(t Tracer, rules []HealthRule) http.Handler {
+	return NewHealthChecker(t, rules)
+}
@@ -0,0 +1,96 @@
+package tracer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHealthChecker(t *testing.T) {
+	tcr := NewTracer()
+	rules := []HealthRule{
+		{Group: "db", Level: LevelError, Window: time.Minute, Fails: MoreThan(2)},
+		{Group: "db", Span: "repl", Level: LevelWarn, Fails: MoreThan(0), Status: HealthDegraded},
+		{Name: "heartbeat missing", Group: "heartbeat", Level: LevelInfo, Window: time.Minute, Fails: FewerThan(1)},
+	}
+	c := NewHealthChecker(tcr, rules)
+	defer c.Close()
+
+	report := c.Check()
+	assertEqual(t, HealthUnhealthy, report.Status)
+	assertEqual(t, HealthHealthy, report.Groups["db"].Status)
+	assertEqual(t, []HealthFailure{{Rule: "heartbeat missing", Count: 0, Status: HealthUnhealthy}}, report.Groups["heartbeat"].Failures)
+
+	tcr.Trace("heartbeat", "tick").Info("alive")
+	tcr.Trace("db", "replication").Warn("lagging")
+	report = c.Check()
+	assertEqual(t, HealthDegraded, report.Status)
+	assertEqual(t, HealthHealthy, report.Groups["heartbeat"].Status)
+	assertEqual(t, []HealthFailure{{Rule: `WARN entries in group "db" span "repl"`, Count: 1, Status: HealthDegraded}}, report.Groups["db"].Failures)
+
+	// repeated entries count as many times as they were logged
+	tcr.Trace("db", "query").Error("timeout")
+	tcr.Trace("db", "query").Error("timeout")
+	tcr.Trace("db", "conn").Error("refused")
+	report = c.Check()
+	assertEqual(t, HealthUnhealthy, report.Status)
+	assertEqual(t, HealthUnhealthy, report.Groups["db"].Status)
+	assertEqual(t, 2, len(report.Groups["db"].Failures))
+	assertEqual(t, `ERROR entries in group "db" within 1m0s`, report.Groups["db"].Failures[0].Rule)
+	assertEqual(t, 3, report.Groups["db"].Failures[0].Count)
+
+	// occurrences count when logged, not when last repeated
+	c.mu.Lock()
+	for i := range c.rules[0].times {
+		c.rules[0].times[i] = c.rules[0].times[i].Add(-time.Hour)
+	}
+	c.mu.Unlock()
+	tcr.Trace("db", "query").Error("timeout")
+	assertEqual(t, uint32(3), slices.Collect(tcr.Entries("db", "query"))[0].Count())
+	report = c.Check()
+	assertEqual(t, HealthDegraded, report.Groups["db"].Status)
+	assertEqual(t, 1, len(report.Groups["db"].Failures))
+}
+
+func TestHealthCheckerSampling(t *testing.T) {
+	tcr := NewTracer()
+	tcr.SetSampling(SamplingRule{Sampler: ProbabilitySampler(0), IncludeErrors: true})
+	c := NewHealthChecker(tcr, []HealthRule{{Group: "db", Level: LevelError, Window: time.Minute, Fails: MoreThan(2)}})
+	defer c.Close()
+
+	for range 3 {
+		tcr.Trace("db", "query").Error("timeout")
+	}
+	assertEqual(t, 0, len(slices.Collect(tcr.Groups())))
+	report := c.Check()
+	assertEqual(t, HealthUnhealthy, report.Status)
+	assertEqual(t, 3, report.Groups["db"].Failures[0].Count)
+}
+
+func TestHealthHandler(t *testing.T) {
+	tcr := NewTracer()
+	h := HealthHandler(tcr, []HealthRule{
+		{Group: "db", Level: LevelError, Fails: MoreThan(0)},
+	})
+
+	serve := func() (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+		var body map[string]any
+		assertNoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec, body
+	}
+
+	rec, body := serve()
+	assertEqual(t, http.StatusOK, rec.Code)
+	assertEqual(t, "healthy", body["status"])
+
+	tcr.Trace("db", "query").Error("boom")
+	rec, body = serve()
+	assertEqual(t, http.StatusServiceUnavailable, rec.Code)
+	assertEqual(t, "unhealthy", body["status"])
+	assertEqual(t, "unhealthy", body["groups"].(map[string]any)["db"].(map[string]any)["status"])
+}
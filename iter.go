@@ -0,0 +1,108 @@
+package tracer
+
+import "iter"
+
+// All returns an iterator over every entry held by the tracer. Groups are
+// visited most recently updated first, then the spans of each group, then
+// the entries of each span, all in recency order.
+//
+// The snapshot is taken when iteration starts, under the read lock, and the
+// lock is released before the first entry is yielded, so it is safe to log
+// from within the loop body.
+func (t *tracer) All() iter.Seq[LogEntry] {
+	return func(yield func(LogEntry) bool) {
+		for _, group := range t.snapshot("", "") {
+			for _, span := range group.spans {
+				for _, entry := range span.entries {
+					if !yield(entry) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// Groups returns an iterator over the group names, most recently updated
+// first.
+func (t *tracer) Groups() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		t.mu.RLock()
+		groups := t.sortedGroups("")
+		t.mu.RUnlock()
+
+		for _, group := range groups {
+			if !yield(group) {
+				return
+			}
+		}
+	}
+}
+
+// Spans returns an iterator over the span names of group, most recently
+// updated first.
+func (t *tracer) Spans(group string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		t.mu.RLock()
+		spans := t.sortedSpans(group, "")
+		t.mu.RUnlock()
+
+		for _, span := range spans {
+			if !yield(span) {
+				return
+			}
+		}
+	}
+}
+
+// Entries returns an iterator over the entries of a single span, most recent
+// first.
+func (t *tracer) Entries(group, span string) iter.Seq[LogEntry] {
+	return func(yield func(LogEntry) bool) {
+		t.mu.RLock()
+		entries := sortedEntries(t.logs[group][span])
+		t.mu.RUnlock()
+
+		for _, entry := range entries {
+			if !yield(entry) {
+				return
+			}
+		}
+	}
+}
+
+// groupSnapshot is a point-in-time copy of the spans of a group.
+type groupSnapshot struct {
+	group string
+	spans []spanSnapshot
+}
+
+// spanSnapshot is a point-in-time copy of the entries of a span, sorted most
+// recent first.
+type spanSnapshot struct {
+	span    string
+	entries []logEntry
+}
+
+// snapshot copies the entries of all spans matching the group and span
+// prefix filters, ordered by group recency, then span recency. Groups with
+// no matching spans are included with an empty span list.
+func (t *tracer) snapshot(groupFilter, spanFilter string) []groupSnapshot {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	groups := t.sortedGroups(groupFilter)
+	out := make([]groupSnapshot, 0, len(groups))
+	for _, group := range groups {
+		spans := t.sortedSpans(group, spanFilter)
+		snap := groupSnapshot{group: group, spans: make([]spanSnapshot, 0, len(spans))}
+		for _, span := range spans {
+			snap.spans = append(snap.spans, spanSnapshot{
+				span:    span,
+				entries: sortedEntries(t.logs[group][span]),
+			})
+		}
+		out = append(out, snap)
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package tracer
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTracerIterators(t *testing.T) {
+	tcr := NewTracer()
+
+	tcr.Trace("server", "run").Info("boot")
+	time.Sleep(time.Millisecond)
+	tcr.Trace("api", "rpc").Info("getUser")
+	time.Sleep(time.Millisecond)
+	tcr.Trace("api", "db").Info("getX")
+	time.Sleep(time.Millisecond)
+	tcr.Trace("api", "db").Warn("oops")
+
+	t.Run("groups", func(t *testing.T) {
+		assertEqual(t, []string{"api", "server"}, slices.Collect(tcr.Groups()))
+	})
+
+	t.Run("spans", func(t *testing.T) {
+		assertEqual(t, []string{"db", "rpc"}, slices.Collect(tcr.Spans("api")))
+		assertEqual(t, 0, len(slices.Collect(tcr.Spans("missing"))))
+	})
+
+	t.Run("entries", func(t *testing.T) {
+		var messages []string
+		for entry := range tcr.Entries("api", "db") {
+			messages = append(messages, entry.Level()+" "+entry.Message())
+		}
+		assertEqual(t, []string{"WARN oops", "INFO getX"}, messages)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		var messages []string
+		for entry := range tcr.All() {
+			messages = append(messages, entry.Group()+"/"+entry.Span()+" "+entry.Message())
+		}
+		assertEqual(t, []string{"api/db oops", "api/db getX", "api/rpc getUser", "server/run boot"}, messages)
+	})
+
+	t.Run("early termination", func(t *testing.T) {
+		n := 0
+		for range tcr.All() {
+			n++
+			if n == 2 {
+				break
+			}
+		}
+		assertEqual(t, 2, n)
+	})
+
+	t.Run("log while iterating", func(t *testing.T) {
+		n := 0
+		for entry := range tcr.All() {
+			tcr.Trace("iter", "loop").Info("saw %s", entry.Message())
+			n++
+		}
+		assertEqual(t, 4, n)
+		assertEqual(t, 4, len(slices.Collect(tcr.Entries("iter", "loop"))))
+	})
+}
@@ -0,0 +1,98 @@
+package tracer
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// WriteJaeger writes the tracer contents in the JSON format accepted by the
+// Jaeger UI "JSON File" import.
+//
+// Each group becomes a trace with a single process named after the group,
+// each span a span covering its oldest to most recent entry, and each entry
+// a span log with its message, level and count as fields.
+func WriteJaeger(w io.Writer, t Tracer) error {
+	traces := []jaegerTrace{}
+	for _, g := range collectGroups(t) {
+		trace := jaegerTrace{
+			TraceID: traceID(g.name),
+			Spans:   make([]jaegerSpan, 0, len(g.spans)),
+			Processes: map[string]jaegerProcess{
+				"p1": {ServiceName: g.name, Tags: []jaegerTag{}},
+			},
+		}
+		for _, s := range g.spans {
+			span := jaegerSpan{
+				TraceID:       trace.TraceID,
+				SpanID:        spanID(g.name, s.name),
+				OperationName: s.name,
+				References:    []jaegerReference{},
+				StartTime:     s.start().UnixMicro(),
+				Duration:      max(s.end().Sub(s.start()).Microseconds(), 1),
+				Tags:          []jaegerTag{},
+				Logs:          make([]jaegerLog, 0, len(s.entries)),
+				ProcessID:     "p1",
+			}
+			for _, entry := range s.entries {
+				span.Logs = append(span.Logs, jaegerLog{
+					Timestamp: entry.Time().UnixMicro(),
+					Fields: []jaegerTag{
+						{Key: "event", Type: "string", Value: entry.Message()},
+						{Key: "level", Type: "string", Value: entry.Level()},
+						{Key: "count", Type: "int64", Value: entry.Count()},
+					},
+				})
+			}
+			if s.hasErrors() {
+				span.Tags = append(span.Tags, jaegerTag{Key: "error", Type: "bool", Value: true})
+			}
+			trace.Spans = append(trace.Spans, span)
+		}
+		traces = append(traces, trace)
+	}
+	return json.NewEncoder(w).Encode(jaegerExport{Data: traces})
+}
+
+type jaegerExport struct {
+	Data []jaegerTrace `json:"data"`
+}
+
+type jaegerTrace struct {
+	TraceID   string                   `json:"traceID"`
+	Spans     []jaegerSpan             `json:"spans"`
+	Processes map[string]jaegerProcess `json:"processes"`
+}
+
+type jaegerSpan struct {
+	TraceID       string            `json:"traceID"`
+	SpanID        string            `json:"spanID"`
+	OperationName string            `json:"operationName"`
+	References    []jaegerReference `json:"references"`
+	StartTime     int64             `json:"startTime"`
+	Duration      int64             `json:"duration"`
+	Tags          []jaegerTag       `json:"tags"`
+	Logs          []jaegerLog       `json:"logs"`
+	ProcessID     string            `json:"processID"`
+}
+
+type jaegerReference struct {
+	RefType string `json:"refType"`
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+}
+
+type jaegerProcess struct {
+	ServiceName string      `json:"serviceName"`
+	Tags        []jaegerTag `json:"tags"`
+}
+
+type jaegerLog struct {
+	Timestamp int64       `json:"timestamp"`
+	Fields    []jaegerTag `json:"fields"`
+}
+
+type jaegerTag struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
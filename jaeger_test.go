@@ -0,0 +1,37 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteJaeger(t *testing.T) {
+	tcr := NewTracer()
+	tcr.Trace("api", "rpc").Info("getUser")
+	tcr.Trace("api", "rpc").Error("boom")
+	tcr.Trace("server", "run").Info("boot")
+
+	var buf bytes.Buffer
+	assertNoError(t, WriteJaeger(&buf, tcr))
+
+	var out jaegerExport
+	assertNoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assertEqual(t, 2, len(out.Data))
+
+	trace := out.Data[1]
+	assertEqual(t, traceID("api"), trace.TraceID)
+	assertEqual(t, "api", trace.Processes["p1"].ServiceName)
+	assertEqual(t, 1, len(trace.Spans))
+
+	span := trace.Spans[0]
+	assertEqual(t, "rpc", span.OperationName)
+	assertEqual(t, "p1", span.ProcessID)
+	assertEqual(t, "error", span.Tags[0].Key)
+	assertEqual(t, 2, len(span.Logs))
+	assertEqual(t, "boom", span.Logs[1].Fields[0].Value)
+	assertEqual(t, "ERROR", span.Logs[1].Fields[1].Value)
+	assertEqual(t, float64(1), span.Logs[1].Fields[2].Value)
+
+	assertEqual(t, 0, len(out.Data[0].Spans[0].Tags))
+}
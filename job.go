@@ -0,0 +1,250 @@
+package tracer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Jobs tracks the runs of background jobs, periodic or queued, recording
+// each run into its own span of the group of its job.
+type Jobs struct {
+	tracer Tracer
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewJobs returns a set of jobs recording their runs into t.
+func NewJobs(t Tracer) *Jobs {
+	return &Jobs{tracer: t, jobs: make(map[string]*Job)}
+}
+
+// Job returns the job with the given name, creating it on first use. Its
+// runs are recorded into the group of the same name.
+func (js *Jobs) Job(name string) *Job {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	job, ok := js.jobs[name]
+	if !ok {
+		job = &Job{name: name, tracer: js.tracer}
+		js.jobs[name] = job
+	}
+	return job
+}
+
+// Summaries returns the summary of every job, sorted by name.
+func (js *Jobs) Summaries() []JobSummary {
+	js.mu.Lock()
+	jobs := make([]*Job, 0, len(js.jobs))
+	for _, job := range js.jobs {
+		jobs = append(jobs, job)
+	}
+	js.mu.Unlock()
+
+	summaries := make([]JobSummary, len(jobs))
+	for i, job := range jobs {
+		summaries[i] = job.Summary()
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries
+}
+
+// ToMap returns the summaries of the jobs keyed by job name, with their
+// times in the given timezone, relative to now unless withExactTime is set,
+// along with their JSON encoding, like Tracer.ToMap. Times are rendered in
+// UTC when timezone is not a valid timezone, see LoadLocation.
+func (js *Jobs) ToMap(timezone string, withExactTime bool) (map[string]map[string]string, []byte) {
+	loc := locationOrUTC(timezone)
+	now := time.Now()
+
+	formatTime := func(t time.Time) string {
+		switch {
+		case t.IsZero():
+			return "never"
+		case withExactTime:
+			return t.In(loc).Format(time.RFC822)
+		case t.After(now):
+			return "in " + t.Sub(now).Round(time.Second).String()
+		default:
+			return RelativeTime{}.Humanize(t.In(loc), now, time.RFC822)
+		}
+	}
+
+	m := make(map[string]map[string]string)
+	for _, s := range js.Summaries() {
+		job := map[string]string{
+			"runs":                 strconv.FormatUint(s.Runs, 10),
+			"failures":             strconv.FormatUint(s.Failures, 10),
+			"consecutive_failures": strconv.FormatUint(s.ConsecutiveFailures, 10),
+			"success_rate":         fmt.Sprintf("%.1f%%", s.SuccessRate()*100),
+			"running":              strconv.FormatBool(s.Running),
+			"last_run":             formatTime(s.LastRun),
+			"last_success":         formatTime(s.LastSuccess),
+			"last_duration":        s.LastDuration.String(),
+		}
+		if s.LastError != "" {
+			job["last_error"] = s.LastError
+		}
+		if !s.NextRun.IsZero() {
+			job["next_run"] = formatTime(s.NextRun)
+		}
+		m[s.Name] = job
+	}
+
+	// a map of strings always marshals
+	data, _ := json.Marshal(m)
+	return m, data
+}
+
+// Job is a background job, see Jobs.
+type Job struct {
+	name   string
+	tracer Tracer
+
+	mu                  sync.Mutex
+	started             uint64
+	runs                uint64
+	failures            uint64
+	consecutiveFailures uint64
+	running             int
+	lastRun             time.Time
+	lastSuccess         time.Time
+	lastDuration        time.Duration
+	lastError           string
+	nextRun             time.Time
+}
+
+// JobSummary is the state of a job.
+type JobSummary struct {
+	Name                string        `json:"name"`
+	Runs                uint64        `json:"runs"`
+	Failures            uint64        `json:"failures"`
+	ConsecutiveFailures uint64        `json:"consecutive_failures"`
+	Running             bool          `json:"running"`
+	LastRun             time.Time     `json:"last_run"`
+	LastSuccess         time.Time     `json:"last_success"`
+	LastDuration        time.Duration `json:"last_duration"`
+	LastError           string        `json:"last_error,omitempty"`
+	NextRun             time.Time     `json:"next_run"`
+}
+
+// SuccessRate returns the ratio of the completed runs which succeeded, or 1
+// if none completed.
+func (s JobSummary) SuccessRate() float64 {
+	if s.Runs == 0 {
+		return 1
+	}
+	return float64(s.Runs-s.Failures) / float64(s.Runs)
+}
+
+// Run runs fn once, in a new span of the job named after the run number,
+// such as "run #42". fn is given that span Logger, also available through
+// LoggerFromContext. The start and end of the run are recorded, the end at
+// ERROR level with the error if fn fails. A panic of fn is recorded and
+// returned as an error.
+func (j *Job) Run(ctx context.Context, fn func(ctx context.Context, l Logger) error) (err error) {
+	j.mu.Lock()
+	j.running++
+	j.started++
+	run := j.started
+	start := time.Now()
+	j.lastRun = start
+	j.mu.Unlock()
+
+	l := j.tracer.Trace(j.name, fmt.Sprintf("run #%d", run))
+	l.Info("start")
+
+	defer func() {
+		if p := recover(); p != nil {
+			recordPanic(l, p)
+			err = fmt.Errorf("tracer: job %s panicked: %v", j.name, p)
+		}
+		duration := time.Since(start)
+		j.finish(start, duration, err)
+
+		l := l.WithAttrs(Attr{"duration", duration.String()})
+		if err != nil {
+			l.WithAttrs(Attr{"outcome", "failure"}).Error("end: %v", err)
+		} else {
+			l.WithAttrs(Attr{"outcome", "success"}).Info("end")
+		}
+	}()
+
+	return fn(ContextWithLogger(ctx, l), l)
+}
+
+func (j *Job) finish(start time.Time, duration time.Duration, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.running--
+	j.runs++
+	j.lastDuration = duration
+	if err != nil {
+		j.failures++
+		j.consecutiveFailures++
+		j.lastError = err.Error()
+	} else {
+		j.consecutiveFailures = 0
+		j.lastSuccess = start
+		j.lastError = ""
+	}
+}
+
+// Every runs fn every interval until ctx is done, the first time after
+// interval, and returns the error of ctx. Runs do not overlap: ticks missed
+// by a slow run are dropped.
+func (j *Job) Every(ctx context.Context, interval time.Duration, fn func(ctx context.Context, l Logger) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	j.SetNextRun(time.Now().Add(interval))
+	for {
+		select {
+		case <-ctx.Done():
+			j.SetNextRun(time.Time{})
+			return ctx.Err()
+		case tick := <-ticker.C:
+			if ctx.Err() != nil {
+				continue // both were ready, stop rather than run
+			}
+			j.SetNextRun(tick.Add(interval))
+			j.Run(ctx, fn)
+		}
+	}
+}
+
+// SetNextRun sets the time of the next run of the job, for jobs which are
+// not run by Every, such as cron-style workers. A zero time clears it.
+func (j *Job) SetNextRun(t time.Time) {
+	j.mu.Lock()
+	j.nextRun = t
+	j.mu.Unlock()
+}
+
+// Summary returns the state of the job.
+func (j *Job) Summary() JobSummary {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return JobSummary{
+		Name:                j.name,
+		Runs:                j.runs,
+		Failures:            j.failures,
+		ConsecutiveFailures: j.consecutiveFailures,
+		Running:             j.running > 0,
+		LastRun:             j.lastRun,
+		LastSuccess:         j.lastSuccess,
+		LastDuration:        j.lastDuration,
+		LastError:           j.lastError,
+		NextRun:             j.nextRun,
+	}
+}
@@ -0,0 +1,123 @@
+package tracer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobRun(t *testing.T) {
+	tcr := NewTracer()
+	jobs := NewJobs(tcr)
+	job := jobs.Job("jobqueue/healthcheck")
+	assertTrue(t, job == jobs.Job("jobqueue/healthcheck"))
+
+	ok := func(ctx context.Context, l Logger) error {
+		LoggerFromContext(ctx).Info("check")
+		return nil
+	}
+	fail := func(ctx context.Context, l Logger) error {
+		return errors.New("db down")
+	}
+
+	assertNoError(t, job.Run(context.Background(), ok))
+	assertTrue(t, job.Run(context.Background(), fail) != nil)
+	err := job.Run(context.Background(), func(ctx context.Context, l Logger) error {
+		panic("boom")
+	})
+	assertEqual(t, "tracer: job jobqueue/healthcheck panicked: boom", err.Error())
+
+	assertEqual(t, []string{"run #3", "run #2", "run #1"}, slices.Collect(tcr.Spans("jobqueue/healthcheck")))
+
+	entries := slices.Collect(tcr.Entries("jobqueue/healthcheck", "run #1"))
+	assertEqual(t, 3, len(entries))
+	assertEqual(t, "end", entries[0].Message())
+	assertEqual(t, "check", entries[1].Message())
+	assertEqual(t, "start", entries[2].Message())
+	assertEqual(t, "outcome", entries[0].Attrs()[1].Key)
+	assertEqual(t, "success", entries[0].Attrs()[1].Value)
+
+	entries = slices.Collect(tcr.Entries("jobqueue/healthcheck", "run #2"))
+	assertEqual(t, "end: db down", entries[0].Message())
+	assertEqual(t, LevelError, entries[0].Level())
+
+	entries = slices.Collect(tcr.Entries("jobqueue/healthcheck", "run #3"))
+	assertEqual(t, 3, len(entries))
+	assertEqual(t, "panic: boom", entries[1].Message())
+
+	s := job.Summary()
+	assertEqual(t, uint64(3), s.Runs)
+	assertEqual(t, uint64(2), s.Failures)
+	assertEqual(t, uint64(2), s.ConsecutiveFailures)
+	assertEqual(t, "tracer: job jobqueue/healthcheck panicked: boom", s.LastError)
+	assertFalse(t, s.Running)
+	assertTrue(t, s.LastSuccess.Before(s.LastRun))
+	assertEqual(t, 1.0/3, s.SuccessRate())
+
+	assertNoError(t, job.Run(context.Background(), ok))
+	s = job.Summary()
+	assertEqual(t, uint64(0), s.ConsecutiveFailures)
+	assertEqual(t, "", s.LastError)
+	assertEqual(t, s.LastRun, s.LastSuccess)
+}
+
+func TestJobEvery(t *testing.T) {
+	tcr := NewTracer()
+	job := NewJobs(tcr).Job("ticker")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	err := job.Every(ctx, 5*time.Millisecond, func(ctx context.Context, l Logger) error {
+		runs++
+		assertTrue(t, job.Summary().NextRun.After(time.Now()))
+		if runs == 3 {
+			cancel()
+		}
+		return nil
+	})
+	assertEqual(t, context.Canceled, err)
+	assertEqual(t, 3, runs)
+	assertEqual(t, uint64(3), job.Summary().Runs)
+	assertTrue(t, job.Summary().NextRun.IsZero())
+}
+
+func TestJobsToMap(t *testing.T) {
+	jobs := NewJobs(NewTracer())
+	jobs.Job("b").Run(context.Background(), func(ctx context.Context, l Logger) error {
+		return errors.New("oops")
+	})
+	jobs.Job("a").SetNextRun(time.Now().Add(time.Minute))
+
+	m, data := jobs.ToMap("UTC", false)
+	assertEqual(t, "never", m["a"]["last_run"])
+	assertTrue(t, strings.HasPrefix(m["a"]["next_run"], "in "))
+	assertEqual(t, "100.0%", m["a"]["success_rate"])
+	assertEqual(t, "0.0%", m["b"]["success_rate"])
+	assertEqual(t, "oops", m["b"]["last_error"])
+	assertEqual(t, "1", m["b"]["consecutive_failures"])
+	assertEqual(t, "0s ago", m["b"]["last_run"])
+	_, ok := m["b"]["next_run"]
+	assertFalse(t, ok)
+
+	var decoded map[string]map[string]string
+	assertNoError(t, json.Unmarshal(data, &decoded))
+	assertEqual(t, m, decoded)
+
+	summaries := jobs.Summaries()
+	assertEqual(t, 2, len(summaries))
+	assertEqual(t, "a", summaries[0].Name)
+
+	// summaries use the same keys as the map
+	data, err := json.Marshal(summaries[1])
+	assertNoError(t, err)
+	var fields map[string]any
+	assertNoError(t, json.Unmarshal(data, &fields))
+	for _, key := range []string{"consecutive_failures", "last_run", "last_success", "last_duration", "last_error", "next_run"} {
+		_, ok := fields[key]
+		assertTrue(t, ok)
+	}
+}
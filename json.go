@@ -0,0 +1,203 @@
+package tracer
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONOptions configures WriteJSON.
+type JSONOptions struct {
+	// Timezone used to render entry times, defaults to UTC. WriteJSON
+	// fails if it is not a valid timezone.
+	Timezone string
+
+	// WithExactTime renders absolute times instead of relative ones.
+	WithExactTime bool
+
+	// GroupFilter and SpanFilter only include groups and spans with the
+	// given name prefix.
+	GroupFilter string
+	SpanFilter  string
+
+	// Pretty indents the output.
+	Pretty bool
+
+	// Raw renders entries as objects with their level, time, count, group,
+	// span, message and attrs as separate fields, instead of preformatted
+	// strings.
+	Raw bool
+}
+
+// WriteJSON streams the tracer contents to w as a JSON object of groups,
+// each an object of spans, each an array of entries. Groups, spans and
+// entries are ordered most recent first, like ToMap. Unlike ToMap, an
+// invalid timezone is reported as an error instead of falling back to UTC.
+func (t *tracer) WriteJSON(w io.Writer, opts JSONOptions) error {
+	loc, err := LoadLocation(opts.Timezone)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := writeJSON(bw, t.snapshot(opts.GroupFilter, opts.SpanFilter), loc, opts, nil); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// JSONHandler returns an http.Handler serving the tracer contents as JSON.
+// The query parameters group, span, tz, exact, pretty and raw map to the
+// JSONOptions fields.
+func JSONHandler(t Tracer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		opts := JSONOptions{
+			Timezone:      q.Get("tz"),
+			WithExactTime: queryBool(q.Get("exact")),
+			GroupFilter:   q.Get("group"),
+			SpanFilter:    q.Get("span"),
+			Pretty:        queryBool(q.Get("pretty")),
+			Raw:           queryBool(q.Get("raw")),
+		}
+		if _, err := LoadLocation(opts.Timezone); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		t.WriteJSON(w, opts)
+	})
+}
+
+func queryBool(v string) bool {
+	b, _ := strconv.ParseBool(v)
+	return b
+}
+
+// jsonEntry is the raw representation of an entry.
+type jsonEntry struct {
+	Group   string    `json:"group"`
+	Span    string    `json:"span"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+	Count   uint32    `json:"count"`
+	Sampled uint32    `json:"sampled,omitempty"`
+	Attrs   []Attr    `json:"attrs,omitempty"`
+}
+
+// writeJSON writes snap to w, rendering times in loc. If collect is not
+// nil, it is called with the formatted entries of each span, which lets
+// ToMap build its map in the same pass.
+func writeJSON(w io.Writer, snap []groupSnapshot, loc *time.Location, opts JSONOptions, collect func(group, span string, entries []string)) error {
+	jw := &jsonWriter{w: w, pretty: opts.Pretty}
+	jw.write(`{`)
+	for i, group := range snap {
+		if i > 0 {
+			jw.write(`,`)
+		}
+		jw.newline(1)
+		jw.key(group.group)
+		jw.write(`{`)
+
+		for j, span := range group.spans {
+			if j > 0 {
+				jw.write(`,`)
+			}
+			jw.newline(2)
+			jw.key(span.span)
+			jw.write(`[`)
+
+			formatted := make([]string, 0, len(span.entries))
+			for k, entry := range span.entries {
+				if k > 0 {
+					jw.write(`,`)
+				}
+				jw.newline(3)
+				if opts.Raw {
+					jw.value(jsonEntry{
+						Group:   entry.group,
+						Span:    entry.span,
+						Level:   entry.level,
+						Message: entry.message,
+						Time:    entry.time.In(loc),
+						Count:   entry.count,
+						Sampled: entry.sampledCount(),
+						Attrs:   entry.attrs,
+					}, 3)
+				} else {
+					msg := entry.entryFormat().render(entry, loc, opts.WithExactTime)
+					formatted = append(formatted, msg)
+					jw.value(msg, 3)
+				}
+			}
+			if len(span.entries) > 0 {
+				jw.newline(2)
+			}
+			jw.write(`]`)
+
+			if collect != nil {
+				collect(group.group, span.span, formatted)
+			}
+		}
+
+		if len(group.spans) > 0 {
+			jw.newline(1)
+		}
+		jw.write(`}`)
+	}
+	if len(snap) > 0 {
+		jw.newline(0)
+	}
+	jw.write(`}`)
+
+	return jw.err
+}
+
+// jsonWriter writes JSON tokens, remembering the first error.
+type jsonWriter struct {
+	w      io.Writer
+	pretty bool
+	err    error
+}
+
+func (jw *jsonWriter) write(s string) {
+	if jw.err != nil {
+		return
+	}
+	_, jw.err = io.WriteString(jw.w, s)
+}
+
+func (jw *jsonWriter) newline(depth int) {
+	if jw.pretty {
+		jw.write("\n" + strings.Repeat("  ", depth))
+	}
+}
+
+func (jw *jsonWriter) key(k string) {
+	jw.value(k, 0)
+	if jw.pretty {
+		jw.write(`: `)
+	} else {
+		jw.write(`:`)
+	}
+}
+
+func (jw *jsonWriter) value(v any, depth int) {
+	if jw.err != nil {
+		return
+	}
+	var b []byte
+	if jw.pretty {
+		b, jw.err = json.MarshalIndent(v, strings.Repeat("  ", depth), "  ")
+	} else {
+		b, jw.err = json.Marshal(v)
+	}
+	if jw.err == nil {
+		_, jw.err = jw.w.Write(b)
+	}
+}
@@ -0,0 +1,89 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWriteJSON(t *testing.T) {
+	tcr := NewTracer()
+	tcr.Trace("server", "run").Info("boot")
+	time.Sleep(time.Millisecond)
+	tcr.Trace("api", "rpc").Info("getUser")
+	time.Sleep(time.Millisecond)
+	tcr.Trace("api", "db").Warn("<slow>")
+	tcr.Trace("api", "db").Warn("<slow>")
+
+	t.Run("matches ToMap", func(t *testing.T) {
+		var buf bytes.Buffer
+		assertNoError(t, tcr.WriteJSON(&buf, JSONOptions{Timezone: "EST"}))
+
+		m, jsonOut := tcr.ToMap("EST", false, "", "")
+		assertEqual(t, string(jsonOut), buf.String())
+		assertEqual(t, `{"api":{"db":["0s ago - [WARN] \u003cslow\u003e [x2]"],"rpc":["0s ago - [INFO] getUser"]},"server":{"run":["0s ago - [INFO] boot"]}}`, buf.String())
+		assertEqual(t, []string{"0s ago - [INFO] getUser"}, m["api"]["rpc"])
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		var buf bytes.Buffer
+		assertNoError(t, tcr.WriteJSON(&buf, JSONOptions{GroupFilter: "a", SpanFilter: "r"}))
+		assertEqual(t, `{"api":{"rpc":["0s ago - [INFO] getUser"]}}`, buf.String())
+
+		m, jsonOut := tcr.ToMap("", false, "", "missing")
+		assertEqual(t, `{"api":{},"server":{}}`, string(jsonOut))
+		assertEqual(t, 0, len(m["api"]))
+	})
+
+	t.Run("pretty", func(t *testing.T) {
+		for _, raw := range []bool{false, true} {
+			var compact, pretty, indented bytes.Buffer
+			assertNoError(t, tcr.WriteJSON(&compact, JSONOptions{Raw: raw}))
+			assertNoError(t, tcr.WriteJSON(&pretty, JSONOptions{Raw: raw, Pretty: true}))
+			assertNoError(t, json.Indent(&indented, compact.Bytes(), "", "  "))
+			assertEqual(t, indented.String(), pretty.String())
+		}
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		var buf bytes.Buffer
+		assertNoError(t, tcr.WriteJSON(&buf, JSONOptions{Raw: true, Timezone: "America/New_York"}))
+
+		var out map[string]map[string][]jsonEntry
+		assertNoError(t, json.Unmarshal(buf.Bytes(), &out))
+		entry := out["api"]["db"][0]
+		assertEqual(t, "api", entry.Group)
+		assertEqual(t, "db", entry.Span)
+		assertEqual(t, LevelWarn, entry.Level)
+		assertEqual(t, "<slow>", entry.Message)
+		assertEqual(t, uint32(2), entry.Count)
+		assertTrue(t, time.Since(entry.Time) < time.Minute)
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		err := tcr.WriteJSON(failingWriter{}, JSONOptions{})
+		assertTrue(t, err != nil)
+	})
+}
+
+func TestJSONHandler(t *testing.T) {
+	tcr := NewTracer()
+	tcr.Trace("api", "rpc").Info("getUser")
+
+	rec := httptest.NewRecorder()
+	JSONHandler(tcr).ServeHTTP(rec, httptest.NewRequest("GET", "/?group=api&raw=1", nil))
+
+	assertEqual(t, "application/json", rec.Header().Get("Content-Type"))
+	var out map[string]map[string][]jsonEntry
+	assertNoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	assertEqual(t, "getUser", out["api"]["rpc"][0].Message)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
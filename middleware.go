@@ -0,0 +1,179 @@
+package tracer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MiddlewareOptions configures Middleware.
+type MiddlewareOptions struct {
+	// Group returns the group of a request, defaults to the request host.
+	Group func(r *http.Request) string
+
+	// Span returns the span of a request, defaults to the method and the
+	// route pattern, such as "GET /users/{id}", or the method alone when no
+	// pattern is known. The path is recorded as an attribute.
+	Span func(r *http.Request) string
+
+	// Mux, if set, is used to resolve the route pattern of requests before
+	// they are dispatched, so the middleware can wrap the whole mux.
+	// Otherwise the pattern is only known when the middleware wraps the
+	// handler of a route.
+	Mux *http.ServeMux
+}
+
+// Middleware returns an http middleware which opens a span per request. The
+// span Logger is available to handlers through LoggerFromContext, and when
+// the request completes its status code, latency and bytes written are
+// recorded as an entry, at WARN level for 4xx and ERROR level for 5xx
+// statuses. Panics are recorded with their stack trace and re-panicked.
+func Middleware(t Tracer, opts MiddlewareOptions) func(http.Handler) http.Handler {
+	if opts.Group == nil {
+		opts.Group = func(r *http.Request) string { return r.Host }
+	}
+	if opts.Span == nil {
+		opts.Span = func(r *http.Request) string { return requestSpan(r, opts.Mux) }
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := t.Trace(opts.Group(r), opts.Span(r))
+			sw := &statusWriter{ResponseWriter: w}
+			start := time.Now()
+
+			defer func() {
+				latency := time.Since(start)
+				if p := recover(); p != nil {
+					recordPanic(l.WithAttrs(
+						Attr{"method", r.Method},
+						Attr{"path", r.URL.Path},
+						Attr{"latency", latency.String()},
+					), p)
+					panic(p)
+				}
+
+				status := sw.status
+				if status == 0 {
+					status = http.StatusOK
+				}
+				attrs := l.WithAttrs(
+					Attr{"method", r.Method},
+					Attr{"path", r.URL.Path},
+					Attr{"status", strconv.Itoa(status)},
+					Attr{"latency", latency.String()},
+					Attr{"bytes", strconv.FormatInt(sw.bytes, 10)},
+				)
+				logStatus(attrs, status)
+			}()
+
+			next.ServeHTTP(sw, r.WithContext(ContextWithLogger(r.Context(), l)))
+		})
+	}
+}
+
+// requestSpan returns the method and route pattern of r, or the method
+// alone when no pattern is known.
+func requestSpan(r *http.Request, mux *http.ServeMux) string {
+	pattern := r.Pattern
+	if mux != nil {
+		_, pattern = mux.Handler(r)
+	}
+	if pattern == "" {
+		// not the path, which would make a span per id
+		return r.Method
+	}
+	// patterns may already start with a method, e.g. "GET /users/{id}"
+	if method, _, ok := strings.Cut(pattern, " "); ok && method == r.Method {
+		return pattern
+	}
+	return r.Method + " " + pattern
+}
+
+type loggerKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying l.
+func ContextWithLogger(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFromContext returns the Logger carried by ctx, or a logger of a
+// disabled tracer, on which every call is a noop.
+func LoggerFromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return l
+	}
+	return noopLogger
+}
+
+var noopLogger = Noop().Group("")
+
+// statusWriter records the status code and number of bytes written.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack lets handlers take over the connection, e.g. for websocket
+// upgrades.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("tracer: hijack: %w", http.ErrNotSupported)
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// logStatus records an HTTP status code to l, at WARN level for 4xx and
+// ERROR level for 5xx statuses.
+func logStatus(l Logger, status int) {
+	msg := fmt.Sprintf("%d %s", status, http.StatusText(status))
+	switch {
+	case status >= 500:
+		l.Error("%s", msg)
+	case status >= 400:
+		l.Warn("%s", msg)
+	default:
+		l.Info("%s", msg)
+	}
+}
@@ -0,0 +1,165 @@
+package tracer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	tcr := NewTracer()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		LoggerFromContext(r.Context()).Info("loading user %s", r.PathValue("id"))
+		w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	h := Middleware(tcr, MiddlewareOptions{Mux: mux})(mux)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "http://api.example.com"+path, nil))
+		return rec
+	}
+
+	t.Run("route pattern", func(t *testing.T) {
+		serve("GET", "/users/1")
+		serve("GET", "/users/2")
+
+		assertEqual(t, []string{"GET /users/{id}"}, slices.Collect(tcr.Spans("api.example.com")))
+
+		entries := slices.Collect(tcr.Entries("api.example.com", "GET /users/{id}"))
+		assertEqual(t, 3, len(entries))
+		assertEqual(t, "200 OK", entries[0].Message())
+		assertEqual(t, uint32(2), entries[0].Count())
+		assertEqual(t, LevelInfo, entries[0].Level())
+		assertEqual(t, "loading user 2", entries[1].Message())
+
+		attrs := map[string]string{}
+		for _, attr := range entries[0].Attrs() {
+			attrs[attr.Key] = attr.Value
+		}
+		assertEqual(t, "GET", attrs["method"])
+		assertEqual(t, "/users/2", attrs["path"])
+		assertEqual(t, "200", attrs["status"])
+		assertEqual(t, "5", attrs["bytes"])
+		assertTrue(t, attrs["latency"] != "")
+	})
+
+	t.Run("status levels", func(t *testing.T) {
+		serve("GET", "/missing")
+		serve("POST", "/fail")
+
+		entries := slices.Collect(tcr.Entries("api.example.com", "GET /missing"))
+		assertEqual(t, 1, len(entries))
+		assertEqual(t, "404 Not Found", entries[0].Message())
+		assertEqual(t, LevelWarn, entries[0].Level())
+
+		entries = slices.Collect(tcr.Entries("api.example.com", "POST /fail"))
+		assertEqual(t, 1, len(entries))
+		assertEqual(t, "502 Bad Gateway", entries[0].Message())
+		assertEqual(t, LevelError, entries[0].Level())
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		func() {
+			defer func() {
+				assertEqual(t, "boom", recover())
+			}()
+			serve("GET", "/panic")
+		}()
+
+		entries := slices.Collect(tcr.Entries("api.example.com", "GET /panic"))
+		assertEqual(t, 1, len(entries))
+		assertEqual(t, "panic: boom", entries[0].Message())
+		assertEqual(t, LevelError, entries[0].Level())
+
+		var stack string
+		for _, attr := range entries[0].Attrs() {
+			if attr.Key == "stack" {
+				stack = attr.Value
+			}
+		}
+		assertTrue(t, strings.Contains(stack, "goroutine"))
+	})
+
+	t.Run("custom group and span", func(t *testing.T) {
+		h := Middleware(tcr, MiddlewareOptions{
+			Group: func(r *http.Request) string { return "web" },
+			Span:  func(r *http.Request) string { return r.URL.Path },
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/about", nil))
+
+		entries := slices.Collect(tcr.Entries("web", "/about"))
+		assertEqual(t, 1, len(entries))
+		assertEqual(t, "200 OK", entries[0].Message())
+	})
+
+	t.Run("no pattern", func(t *testing.T) {
+		h := Middleware(tcr, MiddlewareOptions{Group: func(*http.Request) string { return "plain" }})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/2", nil))
+
+		assertEqual(t, []string{"GET"}, slices.Collect(tcr.Spans("plain")))
+		entries := slices.Collect(tcr.Entries("plain", "GET"))
+		assertEqual(t, uint32(2), entries[0].Count())
+	})
+
+	t.Run("hijack", func(t *testing.T) {
+		h := Middleware(tcr, MiddlewareOptions{Group: func(*http.Request) string { return "ws" }})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			assertNoError(t, err)
+			conn.Close()
+		}))
+		h.ServeHTTP(hijackRecorder{httptest.NewRecorder()}, httptest.NewRequest("GET", "/ws", nil))
+		assertEqual(t, "101 Switching Protocols", slices.Collect(tcr.Entries("ws", "GET"))[0].Message())
+
+		_, _, err := (&statusWriter{ResponseWriter: httptest.NewRecorder()}).Hijack()
+		assertTrue(t, errors.Is(err, http.ErrNotSupported))
+	})
+
+	t.Run("route handler", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mw := Middleware(tcr, MiddlewareOptions{Group: func(*http.Request) string { return "routes" }})
+		mux.Handle("/items/{id}", mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/items/7", nil))
+
+		assertEqual(t, []string{"DELETE /items/{id}"}, slices.Collect(tcr.Spans("routes")))
+	})
+}
+
+func TestLoggerFromContext(t *testing.T) {
+	tcr := NewTracer()
+	ctx := ContextWithLogger(context.Background(), tcr.Trace("g", "s"))
+	LoggerFromContext(ctx).Info("hello")
+	assertEqual(t, 1, len(slices.Collect(tcr.Entries("g", "s"))))
+
+	// without a logger, calls are noops
+	LoggerFromContext(context.Background()).Info("dropped")
+}
+
+// hijackRecorder is a ResponseRecorder whose connection can be hijacked.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, other := net.Pipe()
+	other.Close()
+	return conn, bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)), nil
+}
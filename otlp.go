@@ -0,0 +1,290 @@
+package tracer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OTLPOptions configures an OTLPExporter.
+type OTLPOptions struct {
+	// Endpoint is the base URL of the collector OTLP/HTTP receiver, such
+	// as "http://localhost:4318". Traces are posted to Endpoint/v1/traces
+	// and logs to Endpoint/v1/logs.
+	Endpoint string
+
+	// Headers are added to every export request, e.g. for authentication.
+	Headers map[string]string
+
+	// Client used to post payloads, defaults to http.DefaultClient.
+	Client *http.Client
+
+	// ScopeName is the instrumentation scope name, defaults to
+	// "github.com/goware/tracer".
+	ScopeName string
+}
+
+// OTLPExporter converts tracer contents into OTLP/JSON payloads. Each group
+// becomes a resource with the group as service.name, each span a span with
+// a trace id derived from the group, and each entry a span event and a log
+// record.
+type OTLPExporter struct {
+	opts OTLPOptions
+}
+
+func NewOTLPExporter(opts OTLPOptions) *OTLPExporter {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.ScopeName == "" {
+		opts.ScopeName = "github.com/goware/tracer"
+	}
+	opts.Endpoint = strings.TrimSuffix(opts.Endpoint, "/")
+	return &OTLPExporter{opts: opts}
+}
+
+// Export posts the traces and logs of t to the collector.
+func (e *OTLPExporter) Export(ctx context.Context, t Tracer) error {
+	groups := collectGroups(t)
+
+	traces, err := json.Marshal(e.traces(groups))
+	if err != nil {
+		return err
+	}
+	if err := e.post(ctx, "/v1/traces", traces); err != nil {
+		return err
+	}
+
+	logs, err := json.Marshal(e.logs(groups))
+	if err != nil {
+		return err
+	}
+	return e.post(ctx, "/v1/logs", logs)
+}
+
+// MarshalTraces returns t as an OTLP/JSON ExportTraceServiceRequest.
+func (e *OTLPExporter) MarshalTraces(t Tracer) ([]byte, error) {
+	return json.Marshal(e.traces(collectGroups(t)))
+}
+
+// MarshalLogs returns t as an OTLP/JSON ExportLogsServiceRequest.
+func (e *OTLPExporter) MarshalLogs(t Tracer) ([]byte, error) {
+	return json.Marshal(e.logs(collectGroups(t)))
+}
+
+func (e *OTLPExporter) post(ctx context.Context, path string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.opts.Endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("tracer: otlp export to %s failed: %s", path, resp.Status)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) traces(groups []exportGroup) otlpTracesRequest {
+	req := otlpTracesRequest{ResourceSpans: make([]otlpResourceSpans, 0, len(groups))}
+	for _, g := range groups {
+		spans := make([]otlpSpan, 0, len(g.spans))
+		for _, s := range g.spans {
+			span := otlpSpan{
+				TraceID:           traceID(g.name),
+				SpanID:            spanID(g.name, s.name),
+				Name:              s.name,
+				Kind:              1, // SPAN_KIND_INTERNAL
+				StartTimeUnixNano: otlpTime(s.start()),
+				EndTimeUnixNano:   otlpTime(s.end()),
+				Events:            make([]otlpEvent, 0, len(s.entries)),
+			}
+			for _, entry := range s.entries {
+				span.Events = append(span.Events, otlpEvent{
+					TimeUnixNano: otlpTime(entry.Time()),
+					Name:         entry.Message(),
+					Attributes:   otlpEntryAttributes(entry),
+				})
+			}
+			if s.hasErrors() {
+				span.Status = &otlpStatus{Code: 2} // STATUS_CODE_ERROR
+			}
+			spans = append(spans, span)
+		}
+		req.ResourceSpans = append(req.ResourceSpans, otlpResourceSpans{
+			Resource: otlpResource(g.name),
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: e.opts.ScopeName},
+				Spans: spans,
+			}},
+		})
+	}
+	return req
+}
+
+func (e *OTLPExporter) logs(groups []exportGroup) otlpLogsRequest {
+	req := otlpLogsRequest{ResourceLogs: make([]otlpResourceLogs, 0, len(groups))}
+	for _, g := range groups {
+		var records []otlpLogRecord
+		for _, s := range g.spans {
+			for _, entry := range s.entries {
+				attrs := append([]otlpKeyValue{otlpString("tracelog.span", s.name)}, otlpEntryAttributes(entry)...)
+				records = append(records, otlpLogRecord{
+					TimeUnixNano:         otlpTime(entry.Time()),
+					ObservedTimeUnixNano: otlpTime(entry.Time()),
+					SeverityNumber:       otlpSeverity(entry.Level()),
+					SeverityText:         entry.Level(),
+					Body:                 otlpAnyValue{StringValue: ptr(entry.Message())},
+					Attributes:           attrs,
+					TraceID:              traceID(g.name),
+					SpanID:               spanID(g.name, s.name),
+				})
+			}
+		}
+		req.ResourceLogs = append(req.ResourceLogs, otlpResourceLogs{
+			Resource: otlpResource(g.name),
+			ScopeLogs: []otlpScopeLogs{{
+				Scope:      otlpScope{Name: e.opts.ScopeName},
+				LogRecords: records,
+			}},
+		})
+	}
+	return req
+}
+
+func otlpResource(group string) otlpResourceAttrs {
+	return otlpResourceAttrs{Attributes: []otlpKeyValue{otlpString("service.name", group)}}
+}
+
+func otlpEntryAttributes(entry LogEntry) []otlpKeyValue {
+	attrs := []otlpKeyValue{
+		otlpString("tracelog.level", entry.Level()),
+		{Key: "tracelog.count", Value: otlpAnyValue{IntValue: ptr(strconv.FormatUint(uint64(entry.Count()), 10))}},
+	}
+	for _, attr := range entry.Attrs() {
+		attrs = append(attrs, otlpString(attr.Key, attr.Value))
+	}
+	return attrs
+}
+
+func otlpString(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: ptr(value)}}
+}
+
+// otlpTime encodes t as a fixed64 nanosecond timestamp, which OTLP/JSON
+// represents as a decimal string.
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpSeverity(level string) int {
+	switch level {
+	case LevelWarn:
+		return 13 // SEVERITY_NUMBER_WARN
+	case LevelError:
+		return 17 // SEVERITY_NUMBER_ERROR
+	default:
+		return 9 // SEVERITY_NUMBER_INFO
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// The types below are the subset of the OTLP/JSON encoding of the
+// collector trace and logs service requests needed by OTLPExporter.
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResourceAttrs `json:"resource"`
+	ScopeSpans []otlpScopeSpans  `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Events            []otlpEvent `json:"events"`
+	Status            *otlpStatus `json:"status,omitempty"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes"`
+}
+
+type otlpStatus struct {
+	Code int `json:"code"`
+}
+
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResourceAttrs `json:"resource"`
+	ScopeLogs []otlpScopeLogs   `json:"scopeLogs"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes"`
+	TraceID              string         `json:"traceId"`
+	SpanID               string         `json:"spanId"`
+}
+
+type otlpResourceAttrs struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
@@ -0,0 +1,85 @@
+package tracer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	tcr := NewTracer()
+	tcr.Trace("api", "rpc").Info("getUser")
+	time.Sleep(time.Millisecond)
+	tcr.Trace("api", "rpc").Error("boom")
+	tcr.Trace("server", "run").Info("boot")
+
+	var mu sync.Mutex
+	received := map[string][]byte{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assertEqual(t, "application/json", r.Header.Get("Content-Type"))
+		assertEqual(t, "secret", r.Header.Get("X-Api-Key"))
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received[r.URL.Path] = body
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	exp := NewOTLPExporter(OTLPOptions{
+		Endpoint: collector.URL + "/",
+		Headers:  map[string]string{"X-Api-Key": "secret"},
+	})
+	assertNoError(t, exp.Export(context.Background(), tcr))
+
+	t.Run("traces", func(t *testing.T) {
+		var req otlpTracesRequest
+		assertNoError(t, json.Unmarshal(received["/v1/traces"], &req))
+
+		assertEqual(t, 2, len(req.ResourceSpans))
+		rs := req.ResourceSpans[1]
+		assertEqual(t, "service.name", rs.Resource.Attributes[0].Key)
+		assertEqual(t, "api", *rs.Resource.Attributes[0].Value.StringValue)
+		assertEqual(t, "github.com/goware/tracer", rs.ScopeSpans[0].Scope.Name)
+
+		span := rs.ScopeSpans[0].Spans[0]
+		assertEqual(t, "rpc", span.Name)
+		assertEqual(t, 32, len(span.TraceID))
+		assertEqual(t, 16, len(span.SpanID))
+		assertEqual(t, traceID("api"), span.TraceID)
+		assertEqual(t, 2, span.Status.Code)
+		assertEqual(t, 2, len(span.Events))
+		assertEqual(t, "getUser", span.Events[0].Name)
+		assertEqual(t, "boom", span.Events[1].Name)
+		assertEqual(t, span.StartTimeUnixNano, span.Events[0].TimeUnixNano)
+		assertEqual(t, span.EndTimeUnixNano, span.Events[1].TimeUnixNano)
+
+		assertTrue(t, req.ResourceSpans[0].ScopeSpans[0].Spans[0].Status == nil)
+	})
+
+	t.Run("logs", func(t *testing.T) {
+		var req otlpLogsRequest
+		assertNoError(t, json.Unmarshal(received["/v1/logs"], &req))
+
+		records := req.ResourceLogs[1].ScopeLogs[0].LogRecords
+		assertEqual(t, 2, len(records))
+		assertEqual(t, "ERROR", records[1].SeverityText)
+		assertEqual(t, 17, records[1].SeverityNumber)
+		assertEqual(t, "boom", *records[1].Body.StringValue)
+		assertEqual(t, spanID("api", "rpc"), records[1].SpanID)
+	})
+
+	t.Run("collector error", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer failing.Close()
+
+		err := NewOTLPExporter(OTLPOptions{Endpoint: failing.URL}).Export(context.Background(), tcr)
+		assertTrue(t, err != nil)
+	})
+}
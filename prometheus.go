@@ -0,0 +1,299 @@
+package tracer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PrometheusOtherLabel is the label value under which groups and spans
+// beyond the configured cardinality limits are aggregated.
+const PrometheusOtherLabel = "_other"
+
+// PrometheusOptions configures PrometheusHandler.
+type PrometheusOptions struct {
+	// Namespace is prepended to every metric name, defaults to "tracelog".
+	Namespace string
+
+	// MaxGroups is the maximum number of distinct group label values.
+	// Groups get their own label first come, busiest first among those
+	// seen by the same scrape, and keep it until evicted from the tracer.
+	// The rest are summed under PrometheusOtherLabel. Defaults to
+	// DefaultGroupCount.
+	MaxGroups int
+
+	// MaxSpans is the maximum number of distinct span label values per
+	// group, assigned like MaxGroups. Defaults to DefaultSpanCount.
+	MaxSpans int
+}
+
+// PrometheusHandler returns an http.Handler serving the tracer statistics in
+// the Prometheus text exposition format.
+//
+// Per group and span counters are reset when the group or span is evicted
+// from the tracer, which Prometheus handles as a counter reset. The counters
+// summed under PrometheusOtherLabel keep the counts of the evicted groups
+// and spans, so they never decrease.
+func PrometheusHandler(t Tracer, opts PrometheusOptions) http.Handler {
+	if opts.Namespace == "" {
+		opts.Namespace = "tracelog"
+	}
+	if opts.MaxGroups < 1 {
+		opts.MaxGroups = DefaultGroupCount
+	}
+	if opts.MaxSpans < 1 {
+		opts.MaxSpans = DefaultSpanCount
+	}
+
+	var mu sync.Mutex
+	groups := newPromFolder()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		mu.Lock()
+		writePrometheus(bw, t.Stats(), groups, opts)
+		mu.Unlock()
+		bw.Flush()
+	})
+}
+
+func writePrometheus(w io.Writer, st Stats, folder *promFolder, opts PrometheusOptions) {
+	p := &promWriter{w: w, ns: opts.Namespace}
+
+	enabled := 0
+	if st.Enabled {
+		enabled = 1
+	}
+	p.header("enabled", "gauge", "Whether the tracer is enabled.")
+	p.sample("enabled", nil, uint64(enabled))
+
+	p.header("groups", "gauge", "Number of groups currently held.")
+	p.sample("groups", nil, uint64(st.Groups))
+
+	p.header("messages", "gauge", "Number of entries currently held.")
+	p.sample("messages", nil, uint64(st.Messages))
+
+	p.header("logged_total", "counter", "Messages logged by level.")
+	for _, lc := range st.Logged.byLevel() {
+		p.sample("logged_total", []string{"level", lc.level}, lc.count)
+	}
+
+	p.header("deduplicated_total", "counter", "Messages folded into an existing entry.")
+	p.sample("deduplicated_total", nil, st.Deduplicated)
+
+	p.header("dropped_disabled_total", "counter", "Messages dropped while the tracer was disabled.")
+	p.sample("dropped_disabled_total", nil, st.DroppedDisabled)
+
+	p.header("evictions_total", "counter", "Groups, spans and messages evicted to stay within limits.")
+	p.sample("evictions_total", []string{"kind", "group"}, st.Evicted.Groups)
+	p.sample("evictions_total", []string{"kind", "span"}, st.Evicted.Spans)
+	p.sample("evictions_total", []string{"kind", "message"}, st.Evicted.Messages)
+
+	groups := folder.foldGroups(st.PerGroup, opts.MaxGroups, opts.MaxSpans)
+
+	p.header("spans", "gauge", "Number of spans currently held per group.")
+	for _, g := range groups {
+		p.sample("spans", []string{"group", g.name}, uint64(g.numSpans))
+	}
+
+	p.header("group_messages_total", "counter", "Messages logged per group, span and level.")
+	for _, g := range groups {
+		for _, s := range g.spans {
+			for _, lc := range s.counts.byLevel() {
+				p.sample("group_messages_total", []string{"group", g.name, "span", s.name, "level", lc.level}, lc.count)
+			}
+		}
+	}
+
+	p.header("group_errors_total", "counter", "ERROR messages logged per group and span.")
+	for _, g := range groups {
+		for _, s := range g.spans {
+			p.sample("group_errors_total", []string{"group", g.name, "span", s.name}, s.counts.Error)
+		}
+	}
+}
+
+type levelCount struct {
+	level string
+	count uint64
+}
+
+func (c LevelCounts) byLevel() []levelCount {
+	return []levelCount{
+		{LevelInfo, c.Info},
+		{LevelWarn, c.Warn},
+		{LevelError, c.Error},
+	}
+}
+
+func (c *LevelCounts) merge(o LevelCounts) {
+	c.Info += o.Info
+	c.Warn += o.Warn
+	c.Error += o.Error
+}
+
+type promGroup struct {
+	name     string
+	numSpans int
+	spans    []promSpan
+}
+
+type promSpan struct {
+	name   string
+	counts LevelCounts
+}
+
+// promFolder assigns label values to names first come, and sums the
+// counts of the names beyond the limit under PrometheusOtherLabel. The
+// folders of the spans of named groups are nested in it.
+type promFolder struct {
+	named  map[string]*promFolder // by name, the span folders of groups
+	folded map[string]LevelCounts // last counts of the folded names
+
+	// carried holds the counts of the folded names since evicted or reset,
+	// and other whether any name was folded.
+	carried LevelCounts
+	other   bool
+}
+
+func newPromFolder() *promFolder {
+	return &promFolder{named: make(map[string]*promFolder), folded: make(map[string]LevelCounts)}
+}
+
+// fold updates the label assignments with the current counts, keeping at
+// most limit names, and returns the sum of the folded names.
+func (f *promFolder) fold(counts map[string]LevelCounts, limit int) LevelCounts {
+	for name := range f.named {
+		if _, ok := counts[name]; !ok {
+			// evicted, its label is free again
+			delete(f.named, name)
+		}
+	}
+	for name, last := range f.folded {
+		cur, ok := counts[name]
+		if !ok || cur.Info < last.Info || cur.Warn < last.Warn || cur.Error < last.Error {
+			// evicted, and maybe logged again since
+			f.carried.merge(last)
+			delete(f.folded, name)
+			if ok {
+				f.folded[name] = LevelCounts{}
+			}
+		}
+	}
+
+	var added []string
+	for name := range counts {
+		_, named := f.named[name]
+		_, folded := f.folded[name]
+		if !named && !folded {
+			added = append(added, name)
+		}
+	}
+	sortByVolume(added, func(name string) uint64 { return counts[name].Total() })
+	for _, name := range added {
+		if len(f.named) < limit {
+			f.named[name] = newPromFolder()
+		} else {
+			f.folded[name] = LevelCounts{}
+			f.other = true
+		}
+	}
+
+	other := f.carried
+	for name := range f.folded {
+		f.folded[name] = counts[name]
+		other.merge(counts[name])
+	}
+	return other
+}
+
+// foldGroups orders groups and spans by name, keeping at most maxGroups
+// groups and maxSpans spans per group and summing the rest under
+// PrometheusOtherLabel.
+func (f *promFolder) foldGroups(perGroup map[string]GroupStats, maxGroups, maxSpans int) []promGroup {
+	counts := make(map[string]LevelCounts, len(perGroup))
+	for group, gs := range perGroup {
+		counts[group] = gs.Logged
+	}
+	other := f.fold(counts, maxGroups)
+
+	out := make([]promGroup, 0, len(f.named)+1)
+	for group, spans := range f.named {
+		gs := perGroup[group]
+		out = append(out, promGroup{
+			name:     group,
+			numSpans: len(gs.Spans),
+			spans:    spans.foldSpans(gs.Spans, maxSpans),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+
+	if f.other {
+		g := promGroup{
+			name:  PrometheusOtherLabel,
+			spans: []promSpan{{name: PrometheusOtherLabel, counts: other}},
+		}
+		for group := range f.folded {
+			g.numSpans += len(perGroup[group].Spans)
+		}
+		out = append(out, g)
+	}
+	return out
+}
+
+func (f *promFolder) foldSpans(spans map[string]LevelCounts, maxSpans int) []promSpan {
+	other := f.fold(spans, maxSpans)
+
+	out := make([]promSpan, 0, len(f.named)+1)
+	for span := range f.named {
+		out = append(out, promSpan{name: span, counts: spans[span]})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	if f.other {
+		out = append(out, promSpan{name: PrometheusOtherLabel, counts: other})
+	}
+	return out
+}
+
+// sortByVolume sorts names by descending volume, then by name.
+func sortByVolume(names []string, volume func(string) uint64) {
+	sort.Slice(names, func(i, j int) bool {
+		vi, vj := volume(names[i]), volume(names[j])
+		if vi != vj {
+			return vi > vj
+		}
+		return names[i] < names[j]
+	})
+}
+
+type promWriter struct {
+	w  io.Writer
+	ns string
+}
+
+func (p *promWriter) header(name, kind, help string) {
+	fmt.Fprintf(p.w, "# HELP %s_%s %s\n", p.ns, name, help)
+	fmt.Fprintf(p.w, "# TYPE %s_%s %s\n", p.ns, name, kind)
+}
+
+func (p *promWriter) sample(name string, labels []string, value uint64) {
+	fmt.Fprintf(p.w, "%s_%s", p.ns, name)
+	if len(labels) > 0 {
+		io.WriteString(p.w, "{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				io.WriteString(p.w, ",")
+			}
+			fmt.Fprintf(p.w, `%s="%s"`, labels[i], promLabelEscaper.Replace(labels[i+1]))
+		}
+		io.WriteString(p.w, "}")
+	}
+	fmt.Fprintf(p.w, " %d\n", value)
+}
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,92 @@
+package tracer
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	tcr := NewTracer()
+	tcr.Trace("api", "rpc").Info("getUser")
+	tcr.Trace("api", "rpc").Error("boom")
+	tcr.Trace("api", "db").Info("getX")
+	tcr.Trace("api", "db").Info("getY")
+	tcr.Trace("api", "cache").Warn("miss")
+	tcr.Trace("server", "run").Info("boot")
+	tcr.Trace("server", "run").Info("ready")
+	tcr.Trace("job\"queue", "run").Error("fail")
+
+	h := PrometheusHandler(tcr, PrometheusOptions{MaxGroups: 2, MaxSpans: 2})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assertEqual(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	body, _ := io.ReadAll(rec.Body)
+	out := string(body)
+
+	for _, line := range []string{
+		"# TYPE tracelog_group_messages_total counter",
+		"tracelog_groups 3",
+		`tracelog_logged_total{level="ERROR"} 2`,
+		`tracelog_evictions_total{kind="group"} 0`,
+		`tracelog_spans{group="api"} 3`,
+		`tracelog_group_messages_total{group="api",span="db",level="INFO"} 2`,
+		`tracelog_group_messages_total{group="api",span="rpc",level="ERROR"} 1`,
+		`tracelog_group_errors_total{group="api",span="_other"} 0`,
+		`tracelog_group_messages_total{group="api",span="_other",level="WARN"} 1`,
+		`tracelog_group_messages_total{group="server",span="run",level="INFO"} 2`,
+		`tracelog_group_errors_total{group="_other",span="_other"} 1`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("missing %q in output:\n%s", line, out)
+		}
+	}
+	assertFalse(t, strings.Contains(out, "job"))
+}
+
+func TestPrometheusStableLabels(t *testing.T) {
+	tcr := NewTracer()
+	h := PrometheusHandler(tcr, PrometheusOptions{MaxGroups: 1, MaxSpans: 1})
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		return rec.Body.String()
+	}
+
+	tcr.Trace("a", "run").Info("start")
+	scrape()
+
+	// busier groups do not take the label of a
+	for range 5 {
+		tcr.Trace("b", "run").Error("fail")
+	}
+	tcr.Trace("a", "other").Error("fail")
+	tcr.Trace("a", "other").Error("again")
+	out := scrape()
+	assertTrue(t, strings.Contains(out, `tracelog_group_errors_total{group="a",span="run"} 0`+"\n"))
+	assertTrue(t, strings.Contains(out, `tracelog_group_errors_total{group="a",span="_other"} 2`+"\n"))
+	assertTrue(t, strings.Contains(out, `tracelog_group_errors_total{group="_other",span="_other"} 5`+"\n"))
+
+	// the counts of deleted groups stay in _other, and a free label is
+	// taken by the next new group
+	tcr.DeleteGroup("b")
+	tcr.DeleteGroup("a")
+	tcr.Trace("c", "run").Error("fail")
+	tcr.Trace("d", "run").Error("fail")
+	out = scrape()
+	assertTrue(t, strings.Contains(out, `tracelog_group_errors_total{group="_other",span="_other"} 6`+"\n"))
+	assertFalse(t, strings.Contains(out, `group="a"`))
+	assertTrue(t, strings.Contains(out, `tracelog_group_errors_total{group="c",span="run"} 1`+"\n"))
+}
+
+func TestPrometheusLabelEscaping(t *testing.T) {
+	tcr := NewTracer()
+	tcr.Trace("job\"queue", "a\\b\nc").Info("start")
+
+	rec := httptest.NewRecorder()
+	PrometheusHandler(tcr, PrometheusOptions{Namespace: "app"}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assertTrue(t, strings.Contains(rec.Body.String(), `app_group_messages_total{group="job\"queue",span="a\\b\nc",level="INFO"} 1`))
+}
@@ -0,0 +1,36 @@
+package tracer
+
+import (
+	"runtime/debug"
+)
+
+// Recover records a panic of the current goroutine into an ERROR entry of
+// logger, with its value and stack trace, and re-panics if rethrow is true.
+// It must be deferred directly:
+//
+//	defer tracer.Recover(logger, false)
+func Recover(logger Logger, rethrow bool) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	recordPanic(logger, p)
+	if rethrow {
+		panic(p)
+	}
+}
+
+// Go runs fn in a new goroutine, recording its panic into logger, if any,
+// instead of crashing the program.
+func Go(logger Logger, fn func()) {
+	go func() {
+		defer Recover(logger, false)
+		fn()
+	}()
+}
+
+// recordPanic records the panic value p with the stack trace of the current
+// goroutine.
+func recordPanic(logger Logger, p any) {
+	logger.WithAttrs(Attr{"stack", string(debug.Stack())}).Error("panic: %v", p)
+}
@@ -0,0 +1,65 @@
+package tracer
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecover(t *testing.T) {
+	tcr := NewTracer()
+	l := tcr.Trace("worker", "run")
+
+	func() {
+		defer Recover(l, false)
+		panic("boom")
+	}()
+
+	entries := slices.Collect(tcr.Entries("worker", "run"))
+	assertEqual(t, 1, len(entries))
+	assertEqual(t, "panic: boom", entries[0].Message())
+	assertEqual(t, LevelError, entries[0].Level())
+	attrs := entries[0].Attrs()
+	assertEqual(t, "stack", attrs[0].Key)
+	assertTrue(t, strings.Contains(attrs[0].Value, "TestRecover"))
+
+	func() {
+		defer func() {
+			assertEqual(t, "again", recover())
+		}()
+		defer Recover(l, true)
+		panic("again")
+	}()
+	assertEqual(t, 2, len(slices.Collect(tcr.Entries("worker", "run"))))
+
+	// no panic, nothing recorded
+	func() {
+		defer Recover(l, true)
+	}()
+	assertEqual(t, 2, len(slices.Collect(tcr.Entries("worker", "run"))))
+}
+
+func TestGo(t *testing.T) {
+	tcr := NewTracer()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	Go(tcr.Trace("worker", "async"), func() {
+		defer wg.Done()
+		panic("lost")
+	})
+	wg.Wait()
+
+	// Done runs before Recover, so wait for the entry
+	var entries []LogEntry
+	for range 100 {
+		if entries = slices.Collect(tcr.Entries("worker", "async")); len(entries) > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assertEqual(t, 1, len(entries))
+	assertEqual(t, "panic: lost", entries[0].Message())
+}
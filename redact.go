@@ -0,0 +1,183 @@
+package tracer
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// RedactedText replaces redacted values by default.
+const RedactedText = "[REDACTED]"
+
+// RedactionRule replaces the matches of a pattern in messages and attr
+// values.
+type RedactionRule struct {
+	// Name identifies the rule in the redaction counts.
+	Name string
+
+	// Pattern matches the text to redact.
+	Pattern *regexp.Regexp
+
+	// Replacement for each match, which may reference submatches as in
+	// regexp.Expand. Defaults to RedactedText.
+	Replacement string
+
+	// Valid, if set, filters the matches of Pattern, e.g. with a checksum.
+	// Matches for which it returns false are kept.
+	Valid func(match string) bool
+}
+
+// DefaultRedactedKeys are the attr keys whose values are redacted by
+// DefaultRedactor.
+var DefaultRedactedKeys = []string{
+	"password", "passwd", "secret", "token", "api_key", "apikey", "authorization", "cookie",
+}
+
+// BuiltinRedactionRules returns the built-in detectors for JWTs, bearer
+// tokens, passwords in URLs, email addresses and credit card numbers.
+func BuiltinRedactionRules() []RedactionRule {
+	return []RedactionRule{
+		{
+			Name:    "jwt",
+			Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+		},
+		{
+			Name:        "bearer",
+			Pattern:     regexp.MustCompile(`(?i)\b(bearer)\s+[A-Za-z0-9\-._~+/]+=*`),
+			Replacement: "$1 " + RedactedText,
+		},
+		{
+			Name:        "url_password",
+			Pattern:     regexp.MustCompile(`\b([a-zA-Z][a-zA-Z0-9+.-]*://[^:/@\s]+:)[^@/\s]+@`),
+			Replacement: "${1}" + RedactedText + "@",
+		},
+		{
+			Name:    "email",
+			Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+		},
+		{
+			Name:    "credit_card",
+			Pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+			Valid:   luhnValid,
+		},
+	}
+}
+
+// Redactor removes secrets and personal data from messages and attrs before
+// they are stored by the tracer, see Tracer.SetRedactor. It counts the
+// redactions made by each rule, and under "key" those made by attr key.
+type Redactor struct {
+	rules  []RedactionRule
+	keys   map[string]bool
+	counts map[string]*atomic.Uint64
+}
+
+// NewRedactor returns a redactor applying rules in order, and redacting the
+// whole value of attrs with one of the given keys, compared case
+// insensitively.
+func NewRedactor(rules []RedactionRule, keys ...string) *Redactor {
+	r := &Redactor{
+		rules:  slices.Clone(rules),
+		keys:   make(map[string]bool, len(keys)),
+		counts: map[string]*atomic.Uint64{"key": {}},
+	}
+	for i, rule := range r.rules {
+		if rule.Replacement == "" {
+			r.rules[i].Replacement = RedactedText
+		}
+		r.counts[rule.Name] = &atomic.Uint64{}
+	}
+	for _, key := range keys {
+		r.keys[strings.ToLower(key)] = true
+	}
+	return r
+}
+
+// DefaultRedactor returns a redactor with the built-in rules and the
+// DefaultRedactedKeys.
+func DefaultRedactor() *Redactor {
+	return NewRedactor(BuiltinRedactionRules(), DefaultRedactedKeys...)
+}
+
+// Redact returns s with the matches of every rule replaced.
+func (r *Redactor) Redact(s string) string {
+	for _, rule := range r.rules {
+		count := r.counts[rule.Name]
+		s = rule.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if rule.Valid != nil && !rule.Valid(match) {
+				return match
+			}
+			count.Add(1)
+			if !strings.Contains(rule.Replacement, "$") {
+				return rule.Replacement
+			}
+			return rule.Pattern.ReplaceAllString(match, rule.Replacement)
+		})
+	}
+	return s
+}
+
+// RedactAttrs returns attrs with the values of redacted keys replaced and
+// the other values passed through Redact. attrs is only copied if a value
+// changes.
+func (r *Redactor) RedactAttrs(attrs []Attr) []Attr {
+	var out []Attr
+	for i, attr := range attrs {
+		value := attr.Value
+		if r.keys[strings.ToLower(attr.Key)] {
+			if value != RedactedText {
+				r.counts["key"].Add(1)
+			}
+			value = RedactedText
+		} else {
+			value = r.Redact(value)
+		}
+		if value != attr.Value {
+			if out == nil {
+				out = make([]Attr, len(attrs))
+				copy(out, attrs)
+			}
+			out[i].Value = value
+		}
+	}
+	if out == nil {
+		return attrs
+	}
+	return out
+}
+
+// Counts returns the number of redactions made by each rule.
+func (r *Redactor) Counts() map[string]uint64 {
+	out := make(map[string]uint64, len(r.counts))
+	for name, count := range r.counts {
+		out[name] = count.Load()
+	}
+	return out
+}
+
+func (t *tracer) SetRedactor(r *Redactor) {
+	t.redactor.Store(r)
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum used by
+// payment card numbers.
+func luhnValid(s string) bool {
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
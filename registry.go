@@ -0,0 +1,207 @@
+package tracer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// DefaultTracerName is the name under which the tracer created by Default
+// is registered.
+const DefaultTracerName = "default"
+
+// registry holds the named tracers shared across a program. Every
+// registration gets a new generation, so that RegistryHandler can tell when
+// a name was registered again without comparing tracers.
+var registry = struct {
+	sync.RWMutex
+	tracers map[string]registration
+	def     registration
+	gen     uint64
+}{tracers: make(map[string]registration)}
+
+type registration struct {
+	tracer Tracer
+	gen    uint64
+
+	// isDefault is set on the DefaultTracerName registration made for the
+	// default tracer.
+	isDefault bool
+}
+
+// register returns a registration of t with a new generation. The caller
+// must hold the registry lock.
+func register(t Tracer, isDefault bool) registration {
+	registry.gen++
+	return registration{tracer: t, gen: registry.gen, isDefault: isDefault}
+}
+
+// Register makes t available by name to Get and to RegistryHandler. Names
+// must be unique.
+func Register(name string, t Tracer) error {
+	if name == "" {
+		return fmt.Errorf("tracer: empty tracer name")
+	}
+	if t == nil {
+		return fmt.Errorf("tracer: nil tracer %q", name)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.tracers[name]; ok {
+		return fmt.Errorf("tracer: tracer %q is already registered", name)
+	}
+	registry.tracers[name] = register(t, false)
+	return nil
+}
+
+// Unregister removes the tracer registered by name, if any.
+func Unregister(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.tracers, name)
+}
+
+// Get returns the tracer registered by name.
+func Get(name string) (Tracer, bool) {
+	reg, ok := lookup(name)
+	return reg.tracer, ok
+}
+
+// lookup returns the registration of name, or of the default tracer for
+// the empty name.
+func lookup(name string) (registration, bool) {
+	if name == "" {
+		Default()
+		registry.RLock()
+		defer registry.RUnlock()
+		return registry.def, true
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	reg, ok := registry.tracers[name]
+	return reg, ok
+}
+
+// Registered returns the names of the registered tracers, sorted.
+func Registered() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, 0, len(registry.tracers))
+	for name := range registry.tracers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default returns the default tracer, used by the package-level Trace and
+// Group. Unless set with SetDefault, it is created with NewTracer on first
+// use and registered as DefaultTracerName, if that name is free.
+func Default() Tracer {
+	registry.RLock()
+	t := registry.def.tracer
+	registry.RUnlock()
+	if t != nil {
+		return t
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	if registry.def.tracer == nil {
+		setDefault(NewTracer())
+	}
+	return registry.def.tracer
+}
+
+// SetDefault replaces the default tracer. The tracer registered as
+// DefaultTracerName is replaced too, unless that name was registered with
+// Register. SetDefault(nil) unregisters the default tracer, and the next
+// call to Default creates a new one.
+func SetDefault(t Tracer) {
+	registry.Lock()
+	defer registry.Unlock()
+	if t == nil {
+		if registry.tracers[DefaultTracerName].isDefault {
+			delete(registry.tracers, DefaultTracerName)
+		}
+		registry.def = registration{}
+		return
+	}
+	setDefault(t)
+}
+
+// setDefault makes t the default tracer. The caller must hold the registry
+// lock.
+func setDefault(t Tracer) {
+	registry.def = register(t, true)
+	if cur, ok := registry.tracers[DefaultTracerName]; !ok || cur.isDefault {
+		registry.tracers[DefaultTracerName] = registry.def
+	}
+}
+
+// Trace returns a Logger of the default tracer for group and span.
+func Trace(group, span string) Logger {
+	return Default().Trace(group, span)
+}
+
+// Group returns a Logger of the default tracer for group.
+func Group(group string) Logger {
+	return Default().Group(group)
+}
+
+// RegistryHandler returns an http.Handler switching between the registered
+// tracers: it serves each request with the handler built by fn for the
+// tracer named by the "tracer" query parameter, or for the default tracer
+// without one. Unknown names get a 404. Handlers are built once per
+// registration, and those of unregistered names are dropped when the next
+// handler is built, for example:
+//
+//	http.Handle("/debug/tracelog", tracer.RegistryHandler(tracer.JSONHandler))
+func RegistryHandler(fn func(t Tracer) http.Handler) http.Handler {
+	var mu sync.Mutex
+	handlers := make(map[string]cachedHandler) // by name, "" for the default
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("tracer")
+		reg, ok := lookup(name)
+		if !ok {
+			http.Error(w, fmt.Sprintf("tracer %q is not registered", name), http.StatusNotFound)
+			return
+		}
+
+		mu.Lock()
+		c, ok := handlers[name]
+		if !ok || c.gen != reg.gen {
+			// drop the handlers of the names since unregistered
+			for cached := range handlers {
+				if _, ok := lookup(cached); !ok {
+					delete(handlers, cached)
+				}
+			}
+			c = cachedHandler{gen: reg.gen, handler: fn(reg.tracer)}
+			handlers[name] = c
+		}
+		mu.Unlock()
+
+		c.handler.ServeHTTP(w, r)
+	})
+}
+
+type cachedHandler struct {
+	gen     uint64
+	handler http.Handler
+}
+
+// RegisteredHandler returns an http.Handler listing the names of the
+// registered tracers as JSON, for dashboards to switch between them with
+// RegistryHandler.
+func RegisteredHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string][]string{"tracers": Registered()})
+	})
+}
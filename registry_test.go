@@ -0,0 +1,154 @@
+package tracer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	db, api := NewTracer(), NewTracer()
+	assertNoError(t, Register("test-db", db))
+	defer Unregister("test-db")
+	assertNoError(t, Register("test-api", api))
+	defer Unregister("test-api")
+
+	assertEqual(t, `tracer: tracer "test-db" is already registered`, Register("test-db", api).Error())
+	assertEqual(t, "tracer: empty tracer name", Register("", api).Error())
+
+	got, ok := Get("test-db")
+	assertTrue(t, ok)
+	assertTrue(t, got == db)
+	_, ok = Get("test-missing")
+	assertFalse(t, ok)
+
+	names := Registered()
+	assertTrue(t, slices.Contains(names, "test-db"))
+	assertTrue(t, slices.Contains(names, "test-api"))
+	assertTrue(t, slices.IsSorted(names))
+
+	Unregister("test-api")
+	_, ok = Get("test-api")
+	assertFalse(t, ok)
+}
+
+func TestDefault(t *testing.T) {
+	def := Default()
+	assertTrue(t, def == Default())
+	defer SetDefault(def)
+
+	registered, ok := Get(DefaultTracerName)
+	assertTrue(t, ok)
+	assertTrue(t, registered == def)
+
+	tcr := NewTracer()
+	SetDefault(tcr)
+	assertTrue(t, Default() == tcr)
+	registered, _ = Get(DefaultTracerName)
+	assertTrue(t, registered == tcr)
+
+	Trace("g", "s").Info("hello")
+	Group("g").Span("t").Warn("there")
+	assertEqual(t, 1, len(slices.Collect(tcr.Entries("g", "s"))))
+	assertEqual(t, 1, len(slices.Collect(tcr.Entries("g", "t"))))
+}
+
+func TestSetDefaultNil(t *testing.T) {
+	def := Default()
+	defer SetDefault(def)
+
+	SetDefault(nil)
+	_, ok := Get(DefaultTracerName)
+	assertFalse(t, ok)
+
+	// a new default is created on demand
+	Trace("g", "s").Info("hello")
+	assertTrue(t, Default() != def)
+	registered, ok := Get(DefaultTracerName)
+	assertTrue(t, ok)
+	assertTrue(t, registered == Default())
+
+	// a name registered explicitly is kept
+	Unregister(DefaultTracerName)
+	explicit := NewTracer()
+	assertNoError(t, Register(DefaultTracerName, explicit))
+	defer Unregister(DefaultTracerName)
+	SetDefault(nil)
+	registered, _ = Get(DefaultTracerName)
+	assertTrue(t, registered == explicit)
+	assertEqual(t, "tracer: nil tracer \"test-nil\"", Register("test-nil", nil).Error())
+}
+
+// mapTracer is a Tracer whose dynamic type is not comparable.
+type mapTracer struct {
+	Tracer
+	m map[string]int
+}
+
+func TestRegistryHandler(t *testing.T) {
+	def := Default()
+	tcr := NewTracer()
+	SetDefault(tcr)
+	defer SetDefault(def)
+
+	other := NewTracer()
+	assertNoError(t, Register("test-other", other))
+	defer Unregister("test-other")
+
+	tcr.Trace("default-group", "s").Info("a")
+	other.Trace("other-group", "s").Info("b")
+
+	built := 0
+	h := RegistryHandler(func(t Tracer) http.Handler {
+		built++
+		return JSONHandler(t)
+	})
+	serve := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		return rec
+	}
+
+	rec := serve("/")
+	assertEqual(t, http.StatusOK, rec.Code)
+	assertTrue(t, strings.Contains(rec.Body.String(), "default-group"))
+
+	rec = serve("/?tracer=test-other")
+	assertTrue(t, strings.Contains(rec.Body.String(), "other-group"))
+	assertFalse(t, strings.Contains(rec.Body.String(), "default-group"))
+	serve("/?tracer=test-other&group=other")
+	assertEqual(t, 2, built)
+
+	rec = serve("/?tracer=test-missing")
+	assertEqual(t, http.StatusNotFound, rec.Code)
+
+	// handlers are rebuilt for a new registration of a name
+	Unregister("test-other")
+	rec = serve("/?tracer=test-other")
+	assertEqual(t, http.StatusNotFound, rec.Code)
+	assertNoError(t, Register("test-other", NewTracer()))
+	rec = serve("/?tracer=test-other")
+	assertFalse(t, strings.Contains(rec.Body.String(), "other-group"))
+	assertEqual(t, 3, built)
+	SetDefault(other)
+	serve("/")
+	assertEqual(t, 4, built)
+
+	// tracers need not be comparable
+	assertNoError(t, Register("test-map", mapTracer{Tracer: NewTracer(), m: map[string]int{}}))
+	defer Unregister("test-map")
+	serve("/?tracer=test-map")
+	serve("/?tracer=test-map")
+	assertEqual(t, 5, built)
+
+	rec = httptest.NewRecorder()
+	RegisteredHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	var body struct {
+		Tracers []string `json:"tracers"`
+	}
+	assertNoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assertTrue(t, slices.Contains(body.Tracers, "test-other"))
+}
@@ -0,0 +1,255 @@
+package tracer
+
+import (
+	"hash/maphash"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SampleKey identifies the entries a Sampler keeps state for.
+type SampleKey struct {
+	Group, Span, Level string
+}
+
+// Sampler decides whether to keep a message, before the tracer lock is
+// taken. It is called concurrently.
+type Sampler interface {
+	Sample(key SampleKey, now time.Time) bool
+}
+
+// SamplerFunc adapts a function to the Sampler interface.
+type SamplerFunc func(key SampleKey, now time.Time) bool
+
+func (f SamplerFunc) Sample(key SampleKey, now time.Time) bool {
+	return f(key, now)
+}
+
+// ProbabilitySampler keeps each message with probability p.
+func ProbabilitySampler(p float64) Sampler {
+	return SamplerFunc(func(SampleKey, time.Time) bool {
+		return rand.Float64() < p
+	})
+}
+
+// FirstNSampler keeps the first n messages of each key in every interval.
+func FirstNSampler(n int, interval time.Duration) Sampler {
+	return &firstNSampler{n: n, interval: interval, windows: make(map[SampleKey]*sampleWindow)}
+}
+
+type firstNSampler struct {
+	n        int
+	interval time.Duration
+
+	mu      sync.Mutex
+	windows map[SampleKey]*sampleWindow
+}
+
+type sampleWindow struct {
+	start time.Time
+	n     int
+}
+
+func (s *firstNSampler) Sample(key SampleKey, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.windows[key]
+	if !ok || now.Sub(w.start) >= s.interval {
+		if !ok && len(s.windows) >= maxSampleKeys {
+			// forget the keys whose window is over, they start afresh
+			for k, w := range s.windows {
+				if now.Sub(w.start) >= s.interval {
+					delete(s.windows, k)
+				}
+			}
+		}
+		w = &sampleWindow{start: now}
+		s.windows[key] = w
+	}
+	w.n++
+	return w.n <= s.n
+}
+
+// TokenBucketSampler keeps messages of each key at a sustained rate per
+// second, allowing bursts of up to burst messages.
+func TokenBucketSampler(rate float64, burst int) Sampler {
+	return &tokenBucketSampler{rate: rate, burst: float64(burst), buckets: make(map[SampleKey]*tokenBucket)}
+}
+
+type tokenBucketSampler struct {
+	rate, burst float64
+
+	mu      sync.Mutex
+	buckets map[SampleKey]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (s *tokenBucketSampler) Sample(key SampleKey, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= maxSampleKeys {
+			// forget the full buckets, they start full again
+			for k, b := range s.buckets {
+				if b.tokens+now.Sub(b.last).Seconds()*s.rate >= s.burst {
+					delete(s.buckets, k)
+				}
+			}
+		}
+		b = &tokenBucket{tokens: s.burst, last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = min(s.burst, b.tokens+now.Sub(b.last).Seconds()*s.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// maxSampleKeys is the number of keys above which samplers forget the
+// state of idle keys, and sampling stops counting the suppressed messages
+// of new keys.
+const maxSampleKeys = 1024
+
+// SamplingRule applies a Sampler to the messages it matches.
+type SamplingRule struct {
+	// Group and Span match group and span names by prefix, like the ToMap
+	// filters.
+	Group string
+	Span  string
+
+	// Level matches messages of exactly this level.
+	Level string
+
+	Sampler Sampler
+
+	// IncludeErrors applies the rule to ERROR messages, which are otherwise
+	// always kept.
+	IncludeErrors bool
+}
+
+func (r SamplingRule) matches(group, span, level string) bool {
+	if level == LevelError && !r.IncludeErrors {
+		return false
+	}
+	if r.Level != "" && level != r.Level {
+		return false
+	}
+	return strings.HasPrefix(group, r.Group) && strings.HasPrefix(span, r.Span)
+}
+
+// sampling holds the sampling rules of a tracer, and the number of
+// messages suppressed by key since the last one kept. The counts are
+// sharded by key, and each shard holds at most maxSampleKeys/samplingShards
+// keys: the suppressed messages of keys beyond it are not reported.
+type sampling struct {
+	rules  []SamplingRule
+	seed   maphash.Seed
+	shards [samplingShards]samplingShard
+}
+
+const samplingShards = 16
+
+type samplingShard struct {
+	mu         sync.RWMutex
+	suppressed map[SampleKey]*atomic.Uint32
+}
+
+func newSampling(rules []SamplingRule) *sampling {
+	s := &sampling{rules: rules, seed: maphash.MakeSeed()}
+	for i := range s.shards {
+		s.shards[i].suppressed = make(map[SampleKey]*atomic.Uint32)
+	}
+	return s
+}
+
+// sample reports whether to keep a message, and the number of messages it
+// stands for: itself and those suppressed before it.
+func (s *sampling) sample(group, span, level string, now time.Time) (uint32, bool) {
+	for _, rule := range s.rules {
+		if !rule.matches(group, span, level) {
+			continue
+		}
+		key := SampleKey{group, span, level}
+		keep := rule.Sampler.Sample(key, now)
+
+		sh := s.shard(key)
+		sh.mu.RLock()
+		suppressed := sh.suppressed[key]
+		sh.mu.RUnlock()
+		if keep {
+			if suppressed == nil {
+				return 1, true
+			}
+			return suppressed.Swap(0) + 1, true
+		}
+		if suppressed == nil {
+			suppressed = sh.counter(key)
+		}
+		if suppressed != nil {
+			suppressed.Add(1)
+		}
+		return 0, false
+	}
+	return 1, true
+}
+
+func (s *sampling) shard(key SampleKey) *samplingShard {
+	var h maphash.Hash
+	h.SetSeed(s.seed)
+	h.WriteString(key.Group)
+	h.WriteByte(0)
+	h.WriteString(key.Span)
+	h.WriteByte(0)
+	h.WriteString(key.Level)
+	return &s.shards[h.Sum64()%samplingShards]
+}
+
+// counter returns the suppressed count of key, adding it to the shard, or
+// nil when the shard is full.
+func (sh *samplingShard) counter(key SampleKey) *atomic.Uint32 {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if c, ok := sh.suppressed[key]; ok {
+		return c
+	}
+	if len(sh.suppressed) >= maxSampleKeys/samplingShards {
+		// forget the keys with nothing to report, a message suppressed
+		// concurrently may go unreported
+		for k, c := range sh.suppressed {
+			if c.Load() == 0 {
+				delete(sh.suppressed, k)
+			}
+		}
+		if len(sh.suppressed) >= maxSampleKeys/samplingShards {
+			return nil
+		}
+	}
+	c := new(atomic.Uint32)
+	sh.suppressed[key] = c
+	return c
+}
+
+// SetSampling replaces the sampling rules of the tracer. The first rule
+// matching a message decides whether it is kept; messages matching no rule
+// are kept. Kept messages record how many they stand for, rendered as
+// "[sampled 1/N]" by the default entry format. No rules disable sampling.
+func (t *tracer) SetSampling(rules ...SamplingRule) {
+	if len(rules) == 0 {
+		t.sampling.Store(nil)
+		return
+	}
+	t.sampling.Store(newSampling(rules))
+}
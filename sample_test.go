@@ -0,0 +1,142 @@
+package tracer
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFirstNSampler(t *testing.T) {
+	s := FirstNSampler(2, time.Second)
+	now := time.Now()
+	a, b := SampleKey{"g", "a", LevelInfo}, SampleKey{"g", "b", LevelInfo}
+
+	assertTrue(t, s.Sample(a, now))
+	assertTrue(t, s.Sample(a, now))
+	assertFalse(t, s.Sample(a, now))
+	assertTrue(t, s.Sample(b, now))
+	assertFalse(t, s.Sample(a, now.Add(999*time.Millisecond)))
+	assertTrue(t, s.Sample(a, now.Add(time.Second)))
+}
+
+func TestTokenBucketSampler(t *testing.T) {
+	s := TokenBucketSampler(10, 2)
+	now := time.Now()
+	key := SampleKey{"g", "s", LevelInfo}
+
+	assertTrue(t, s.Sample(key, now))
+	assertTrue(t, s.Sample(key, now))
+	assertFalse(t, s.Sample(key, now))
+	assertFalse(t, s.Sample(key, now.Add(50*time.Millisecond)))
+	assertTrue(t, s.Sample(key, now.Add(100*time.Millisecond)))
+	assertFalse(t, s.Sample(key, now.Add(100*time.Millisecond)))
+	// refills up to the burst
+	assertTrue(t, s.Sample(key, now.Add(time.Hour)))
+	assertTrue(t, s.Sample(key, now.Add(time.Hour)))
+	assertFalse(t, s.Sample(key, now.Add(time.Hour)))
+}
+
+func TestProbabilitySampler(t *testing.T) {
+	key := SampleKey{"g", "s", LevelInfo}
+	assertTrue(t, ProbabilitySampler(1).Sample(key, time.Now()))
+	assertFalse(t, ProbabilitySampler(0).Sample(key, time.Now()))
+
+	kept := 0
+	s := ProbabilitySampler(0.5)
+	for range 1000 {
+		if s.Sample(key, time.Now()) {
+			kept++
+		}
+	}
+	assertTrue(t, kept > 350 && kept < 650)
+}
+
+func TestSetSampling(t *testing.T) {
+	tcr := NewTracer()
+	tcr.SetSampling(
+		SamplingRule{Group: "api", Span: "health", Sampler: FirstNSampler(1, time.Hour)},
+		SamplingRule{Group: "api", Level: LevelWarn, Sampler: ProbabilitySampler(0)},
+	)
+
+	l := tcr.Trace("api", "health")
+	for range 100 {
+		l.Info("ok")
+	}
+	l.Error("down")
+	l.Error("down")
+	l.Warn("slow")
+	tcr.Trace("api", "users").Info("get")
+
+	// each level is sampled on its own, and the first rule matches WARN
+	entries := slices.Collect(tcr.Entries("api", "health"))
+	assertEqual(t, 3, len(entries))
+	assertEqual(t, "slow", entries[0].Message())
+	assertEqual(t, "down", entries[1].Message())
+	assertEqual(t, uint32(2), entries[1].Count())
+	assertEqual(t, "ok", entries[2].Message())
+	assertEqual(t, uint32(1), entries[2].Count())
+	tcr.Trace("api", "users").Warn("slow")
+	assertEqual(t, 1, len(slices.Collect(tcr.Entries("api", "users"))))
+	assertEqual(t, uint64(100), tcr.Stats().DroppedSampled)
+
+	// the suppressed messages are reported on the next one kept
+	tcr.SetSampling(SamplingRule{Group: "db", Sampler: SamplerFunc(func(key SampleKey, now time.Time) bool {
+		return tcr.Stats().DroppedSampled%10 == 9
+	})})
+	for range 10 {
+		tcr.Trace("db", "query").Info("select")
+	}
+	m, _ := tcr.ToMap("", false, "db", "")
+	assertEqual(t, "0s ago - [INFO] select [sampled 1/10]", m["db"]["query"][0])
+
+	// no rules disable sampling
+	tcr.SetSampling()
+	for range 10 {
+		tcr.Trace("db", "query").Info("select")
+	}
+	entries = slices.Collect(tcr.Entries("db", "query"))
+	assertEqual(t, uint32(11), entries[0].Count())
+	assertEqual(t, uint64(109), tcr.Stats().DroppedSampled)
+	m, _ = tcr.ToMap("", false, "db", "")
+	assertEqual(t, "0s ago - [INFO] select [x11]", m["db"]["query"][0])
+}
+
+func TestSamplingKeyLimit(t *testing.T) {
+	drop := true
+	s := newSampling([]SamplingRule{{Sampler: SamplerFunc(func(SampleKey, time.Time) bool { return !drop })}})
+	now := time.Now()
+	for i := range 2 * maxSampleKeys {
+		_, keep := s.sample("g", strconv.Itoa(i), LevelInfo, now)
+		assertFalse(t, keep)
+	}
+	s.sample("g", "0", LevelInfo, now)
+
+	keys := 0
+	for i := range s.shards {
+		keys += len(s.shards[i].suppressed)
+	}
+	assertTrue(t, keys <= maxSampleKeys)
+
+	drop = false
+	n, keep := s.sample("g", "0", LevelInfo, now)
+	assertTrue(t, keep)
+	assertEqual(t, uint32(3), n)
+	n, _ = s.sample("g", "0", LevelInfo, now)
+	assertEqual(t, uint32(1), n)
+
+	// keys with nothing to report make room for new ones
+	drop = true
+	for i := range 2 * maxSampleKeys {
+		s.sample("h", strconv.Itoa(i), LevelInfo, now)
+	}
+	tracked := 0
+	for i := range s.shards {
+		for key := range s.shards[i].suppressed {
+			if key.Group == "h" {
+				tracked++
+			}
+		}
+	}
+	assertTrue(t, tracked > 0)
+}
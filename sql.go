@@ -0,0 +1,345 @@
+package tracer
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLOptions configures WrapDriver and WrapConnector.
+type SQLOptions struct {
+	// Group is the group of the database, defaults to "sql".
+	Group string
+
+	// SlowQuery is the duration above which statements are recorded at
+	// WARN level. Zero disables it.
+	SlowQuery time.Duration
+}
+
+// WrapDriver returns a driver.Driver recording the statements and
+// transactions of the connections opened by d into t. Each operation is
+// recorded into a span named after it, "exec", "query", "prepare", "begin",
+// "commit" or "rollback", with the QueryFingerprint of its query as the
+// message so that repeated queries are deduplicated, and its args count,
+// rows affected and duration as attrs. Failed operations are recorded at
+// ERROR level. Register it with sql.Register, or use WrapConnector with
+// sql.OpenDB.
+func WrapDriver(t Tracer, d driver.Driver, opts SQLOptions) driver.Driver {
+	return &sqlDriver{Driver: d, s: newSQLTracer(t, opts)}
+}
+
+// WrapConnector is the driver.Connector counterpart of WrapDriver.
+func WrapConnector(t Tracer, c driver.Connector, opts SQLOptions) driver.Connector {
+	s := newSQLTracer(t, opts)
+	return &sqlConnector{Connector: c, s: s, driver: &sqlDriver{Driver: c.Driver(), s: s}}
+}
+
+var (
+	sqlComment    = regexp.MustCompile(`--[^\n]*|/\*(?s:.*?)\*/`)
+	sqlString     = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumber     = regexp.MustCompile(`([^\w$.]|^)-?\d+(?:\.\d+)?(?:[eE][-+]?\d+)?\b`)
+	sqlList       = regexp.MustCompile(`\?(?:\s*,\s*\?)+`)
+	sqlWhitespace = regexp.MustCompile(`\s+`)
+)
+
+// QueryFingerprint normalizes query so that queries differing only by
+// their literal values share a fingerprint: comments are removed, string
+// and number literals are replaced by "?", lists of placeholders are
+// collapsed and whitespace is squeezed. Placeholders such as $1 are kept.
+func QueryFingerprint(query string) string {
+	query = sqlComment.ReplaceAllString(query, " ")
+	query = sqlString.ReplaceAllString(query, "?")
+	query = sqlNumber.ReplaceAllString(query, "$1?")
+	query = sqlList.ReplaceAllString(query, "?")
+	query = sqlWhitespace.ReplaceAllString(query, " ")
+	return strings.TrimSpace(query)
+}
+
+type sqlTracer struct {
+	tracer Tracer
+	opts   SQLOptions
+}
+
+func newSQLTracer(t Tracer, opts SQLOptions) *sqlTracer {
+	if opts.Group == "" {
+		opts.Group = "sql"
+	}
+	return &sqlTracer{tracer: t, opts: opts}
+}
+
+// record records an operation of the given span. args is omitted when
+// negative and query when empty.
+func (s *sqlTracer) record(span, query string, args int, result driver.Result, start time.Time, err error) {
+	if err == driver.ErrSkip {
+		// the operation is retried by database/sql, e.g. through a prepare
+		return
+	}
+	duration := time.Since(start)
+
+	msg := span
+	attrs := []Attr{{"duration", duration.String()}}
+	if query != "" {
+		msg = QueryFingerprint(query)
+	}
+	if args >= 0 {
+		attrs = append(attrs, Attr{"args", strconv.Itoa(args)})
+	}
+	if result != nil {
+		if rows, err := result.RowsAffected(); err == nil {
+			attrs = append(attrs, Attr{"rows", strconv.FormatInt(rows, 10)})
+		}
+	}
+
+	l := s.tracer.Trace(s.opts.Group, span)
+	switch {
+	case err != nil:
+		l.WithAttrs(append(attrs, Attr{"error", err.Error()})...).Error("%s: %v", msg, err)
+	case s.opts.SlowQuery > 0 && duration > s.opts.SlowQuery:
+		l.WithAttrs(attrs...).Warn("%s", msg)
+	default:
+		l.WithAttrs(attrs...).Info("%s", msg)
+	}
+}
+
+type sqlDriver struct {
+	driver.Driver
+	s *sqlTracer
+}
+
+func (d *sqlDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{Conn: conn, s: d.s}, nil
+}
+
+func (d *sqlDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.Driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &sqlConnector{Connector: c, s: d.s, driver: d}, nil
+	}
+	return &sqlConnector{Connector: dsnConnector{name: name, driver: d.Driver}, s: d.s, driver: d}, nil
+}
+
+// dsnConnector is the connector of drivers which do not implement
+// driver.DriverContext.
+type dsnConnector struct {
+	name   string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type sqlConnector struct {
+	driver.Connector
+	s      *sqlTracer
+	driver *sqlDriver
+}
+
+func (c *sqlConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{Conn: conn, s: c.s}, nil
+}
+
+func (c *sqlConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type sqlConn struct {
+	driver.Conn
+	s *sqlTracer
+}
+
+func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	start := time.Now()
+	var stmt driver.Stmt
+	var err error
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = pc.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	c.s.record("prepare", query, -1, nil, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlStmt{Stmt: stmt, query: query, s: c.s}, nil
+}
+
+func (c *sqlConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *sqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	start := time.Now()
+	var tx driver.Tx
+	var err error
+	if bc, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = bc.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	c.s.record("begin", "", -1, nil, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTx{Tx: tx, s: c.s}, nil
+}
+
+func (c *sqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := ec.ExecContext(ctx, query, args)
+	c.s.record("exec", query, len(args), result, start, err)
+	return result, err
+}
+
+func (c *sqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := qc.QueryContext(ctx, query, args)
+	c.s.record("query", query, len(args), nil, start, err)
+	return rows, err
+}
+
+func (c *sqlConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *sqlConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *sqlConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *sqlConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type sqlStmt struct {
+	driver.Stmt
+	query string
+	s     *sqlTracer
+}
+
+func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	result, err := s.Stmt.Exec(args)
+	s.s.record("exec", s.query, len(args), result, start, err)
+	return result, err
+}
+
+func (s *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.Stmt.Query(args)
+	s.s.record("query", s.query, len(args), nil, start, err)
+	return rows, err
+}
+
+func (s *sqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	sc, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Exec(values)
+	}
+	start := time.Now()
+	result, err := sc.ExecContext(ctx, args)
+	s.s.record("exec", s.query, len(args), result, start, err)
+	return result, err
+}
+
+func (s *sqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	sc, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Query(values)
+	}
+	start := time.Now()
+	rows, err := sc.QueryContext(ctx, args)
+	s.s.record("query", s.query, len(args), nil, start, err)
+	return rows, err
+}
+
+func (s *sqlStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, fmt.Errorf("tracer: driver does not support named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+type sqlTx struct {
+	driver.Tx
+	s *sqlTracer
+}
+
+func (tx *sqlTx) Commit() error {
+	start := time.Now()
+	err := tx.Tx.Commit()
+	tx.s.record("commit", "", -1, nil, start, err)
+	return err
+}
+
+func (tx *sqlTx) Rollback() error {
+	start := time.Now()
+	err := tx.Tx.Rollback()
+	tx.s.record("rollback", "", -1, nil, start, err)
+	return err
+}
@@ -0,0 +1,182 @@
+package tracer
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryFingerprint(t *testing.T) {
+	for _, tt := range []struct{ in, out string }{
+		{"SELECT * FROM users WHERE id = 42", "SELECT * FROM users WHERE id = ?"},
+		{"select *\n  from users -- all of them\n where name = 'O''Brien'", "select * from users where name = ?"},
+		{"SELECT a FROM t WHERE id IN (1, 2, 3) AND x = -1.5e3", "SELECT a FROM t WHERE id IN (?) AND x = ?"},
+		{"UPDATE t2 SET v = $1 /* bump */ WHERE id = $2", "UPDATE t2 SET v = $1 WHERE id = $2"},
+		{"INSERT INTO t (a, b) VALUES (?, ?)", "INSERT INTO t (a, b) VALUES (?)"},
+	} {
+		assertEqual(t, tt.out, QueryFingerprint(tt.in))
+	}
+}
+
+func TestWrapConnector(t *testing.T) {
+	tcr := NewTracer()
+	db := sql.OpenDB(WrapConnector(tcr, fakeConnector{}, SQLOptions{Group: "users-db", SlowQuery: 20 * time.Millisecond}))
+	defer db.Close()
+
+	_, err := db.Exec("INSERT INTO users VALUES (1, 'ann')")
+	assertNoError(t, err)
+	_, err = db.Exec("INSERT INTO users VALUES (?, ?)", 2, "bob")
+	assertNoError(t, err)
+
+	entries := slices.Collect(tcr.Entries("users-db", "exec"))
+	assertEqual(t, 1, len(entries))
+	assertEqual(t, "INSERT INTO users VALUES (?)", entries[0].Message())
+	assertEqual(t, uint32(2), entries[0].Count())
+	assertEqual(t, []Attr{{"duration", entries[0].Attrs()[0].Value}, {"args", "2"}, {"rows", "1"}}, entries[0].Attrs())
+
+	// the fake conn has no QueryerContext, so queries go through a prepare
+	rows, err := db.Query("SELECT name FROM users WHERE id = ?", 1)
+	assertNoError(t, err)
+	assertNoError(t, rows.Close())
+	assertEqual(t, 1, len(slices.Collect(tcr.Entries("users-db", "prepare"))))
+	entries = slices.Collect(tcr.Entries("users-db", "query"))
+	assertEqual(t, 1, len(entries))
+	assertEqual(t, "SELECT name FROM users WHERE id = ?", entries[0].Message())
+	assertEqual(t, LevelInfo, entries[0].Level())
+
+	_, err = db.Exec("SELECT sleep")
+	assertNoError(t, err)
+	entries = slices.Collect(tcr.Entries("users-db", "exec"))
+	assertEqual(t, "SELECT sleep", entries[0].Message())
+	assertEqual(t, LevelWarn, entries[0].Level())
+
+	_, err = db.Exec("DELETE FROM fail")
+	assertTrue(t, err != nil)
+	entries = slices.Collect(tcr.Entries("users-db", "exec"))
+	assertEqual(t, "DELETE FROM fail: fake failure", entries[0].Message())
+	assertEqual(t, LevelError, entries[0].Level())
+
+	tx, err := db.Begin()
+	assertNoError(t, err)
+	assertNoError(t, tx.Commit())
+	tx, err = db.Begin()
+	assertNoError(t, err)
+	assertNoError(t, tx.Rollback())
+	for _, span := range []string{"begin", "commit", "rollback"} {
+		entries = slices.Collect(tcr.Entries("users-db", span))
+		assertEqual(t, 1, len(entries))
+		assertEqual(t, span, entries[0].Message())
+	}
+}
+
+func TestWrapDriver(t *testing.T) {
+	tcr := NewTracer()
+	// open it as sql.Open would, without registering it globally
+	connector, err := WrapDriver(tcr, fakeDriver{}, SQLOptions{}).(driver.DriverContext).OpenConnector("")
+	assertNoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	_, err = db.ExecContext(context.Background(), "DELETE FROM users")
+	assertNoError(t, err)
+	assertEqual(t, []string{"exec"}, slices.Collect(tcr.Spans("sql")))
+}
+
+// fakeDriver is a minimal driver.Driver, whose statements fail when their
+// query contains "fail" and are slow when it contains "sleep".
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+func (fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{query: query}, nil
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return fakeExec(query)
+}
+
+func fakeExec(query string) (driver.Result, error) {
+	if strings.Contains(query, "fail") {
+		return nil, errors.New("fake failure")
+	}
+	if strings.Contains(query, "sleep") {
+		time.Sleep(30 * time.Millisecond)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeStmt struct {
+	query string
+}
+
+func (fakeStmt) Close() error {
+	return nil
+}
+
+func (fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return fakeExec(s.query)
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if _, err := fakeExec(s.query); err != nil {
+		return nil, err
+	}
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string {
+	return []string{"name"}
+}
+
+func (fakeRows) Close() error {
+	return nil
+}
+
+func (fakeRows) Next(dest []driver.Value) error {
+	return io.EOF
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error {
+	return nil
+}
+
+func (fakeTx) Rollback() error {
+	return nil
+}
@@ -0,0 +1,276 @@
+package tracer
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Stats is a point-in-time summary of what a tracer has recorded and what it
+// has thrown away to stay within its limits.
+type Stats struct {
+	Enabled bool `json:"enabled"`
+
+	// Groups, Spans and Messages held by the tracer right now.
+	Groups   int `json:"groups"`
+	Spans    int `json:"spans"`
+	Messages int `json:"messages"`
+
+	// Logged counts every message accepted since the tracer was created,
+	// including those folded into an existing entry.
+	Logged LevelCounts `json:"logged"`
+
+	// Deduplicated counts messages which incremented the count of an
+	// existing entry instead of adding a new one.
+	Deduplicated uint64 `json:"deduplicated"`
+
+	// Evicted counts groups, spans and messages dropped to make room for
+	// newer ones. Spans and messages dropped along with an evicted group or
+	// span are included.
+	Evicted Evictions `json:"evicted"`
+
+	// Suppressed counts messages folded into the summary entry of a burst,
+	// see Tracer.SetBurstSuppression.
+	Suppressed uint64 `json:"suppressed"`
+
+	// DroppedDisabled counts messages discarded while the tracer was
+	// disabled.
+	DroppedDisabled uint64 `json:"dropped_disabled"`
+
+	// DroppedLevel counts messages discarded for being below the minimum
+	// level of their group.
+	DroppedLevel uint64 `json:"dropped_level"`
+
+	// DroppedSampled counts messages discarded by sampling, see
+	// Tracer.SetSampling.
+	DroppedSampled uint64 `json:"dropped_sampled"`
+
+	// Pinned lists the groups exempt from eviction, and MinLevels the
+	// minimum level of each group which has one.
+	Pinned    []string          `json:"pinned"`
+	MinLevels map[string]string `json:"min_levels"`
+
+	// Rate1, Rate5 and Rate15 are the exponentially weighted moving
+	// averages of messages logged per second over 1, 5 and 15 minutes.
+	Rate1  float64 `json:"rate1"`
+	Rate5  float64 `json:"rate5"`
+	Rate15 float64 `json:"rate15"`
+
+	// Redactions counts the redactions made by the redactor of the tracer,
+	// by rule name. See Redactor.Counts.
+	Redactions map[string]uint64 `json:"redactions,omitempty"`
+
+	// PerGroup holds the counts of the groups currently held, keyed by
+	// group name. Counts are forgotten when a group or span is evicted.
+	PerGroup map[string]GroupStats `json:"per_group"`
+}
+
+// GroupStats holds the counts of a single group and its spans.
+type GroupStats struct {
+	Logged LevelCounts            `json:"logged"`
+	Spans  map[string]LevelCounts `json:"spans"`
+}
+
+// LevelCounts holds message counts by level.
+type LevelCounts struct {
+	Info  uint64 `json:"info"`
+	Warn  uint64 `json:"warn"`
+	Error uint64 `json:"error"`
+}
+
+// Total returns the sum of the counts of all levels.
+func (c LevelCounts) Total() uint64 {
+	return c.Info + c.Warn + c.Error
+}
+
+func (c *LevelCounts) add(level string) {
+	switch level {
+	case LevelInfo:
+		c.Info++
+	case LevelWarn:
+		c.Warn++
+	case LevelError:
+		c.Error++
+	}
+}
+
+// Evictions holds the number of groups, spans and messages evicted.
+type Evictions struct {
+	Groups   uint64 `json:"groups"`
+	Spans    uint64 `json:"spans"`
+	Messages uint64 `json:"messages"`
+}
+
+func (t *tracer) Stats() Stats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	rates := t.stats.rates
+	rates.tick(time.Now())
+
+	st := Stats{
+		Enabled:         t.enabled,
+		Groups:          len(t.logs),
+		Logged:          t.stats.total,
+		Deduplicated:    t.stats.deduplicated,
+		Evicted:         t.stats.evicted,
+		Suppressed:      t.stats.suppressed,
+		DroppedDisabled: t.droppedDisabled.Load(),
+		DroppedLevel:    t.droppedLevel.Load(),
+		DroppedSampled:  t.droppedSampled.Load(),
+		Pinned:          make([]string, 0, len(t.pinned)),
+		MinLevels:       make(map[string]string),
+		Rate1:           rates.ewma[0].rate,
+		Rate5:           rates.ewma[1].rate,
+		Rate15:          rates.ewma[2].rate,
+		PerGroup:        make(map[string]GroupStats, len(t.stats.groups)),
+	}
+
+	for group := range t.pinned {
+		st.Pinned = append(st.Pinned, group)
+	}
+	sort.Strings(st.Pinned)
+
+	if levels := t.minLevels.Load(); levels != nil {
+		for group, rank := range *levels {
+			st.MinLevels[group] = levelNames[rank]
+		}
+	}
+
+	if r := t.redactor.Load(); r != nil {
+		st.Redactions = r.Counts()
+	}
+
+	for _, spans := range t.logs {
+		st.Spans += len(spans)
+		for _, entries := range spans {
+			st.Messages += len(entries)
+		}
+	}
+
+	for group, gs := range t.stats.groups {
+		out := GroupStats{
+			Logged: gs.total,
+			Spans:  make(map[string]LevelCounts, len(gs.spans)),
+		}
+		for span, counts := range gs.spans {
+			out.Spans[span] = *counts
+		}
+		st.PerGroup[group] = out
+	}
+
+	return st
+}
+
+// tracerStats holds the counters behind Stats. It is guarded by tracer.mu.
+type tracerStats struct {
+	total        LevelCounts
+	deduplicated uint64
+	evicted      Evictions
+	suppressed   uint64
+	groups       map[string]*groupStats
+	rates        rateMeter
+}
+
+type groupStats struct {
+	total LevelCounts
+	spans map[string]*LevelCounts
+}
+
+func newTracerStats() tracerStats {
+	return tracerStats{
+		groups: make(map[string]*groupStats),
+		rates:  newRateMeter(time.Now()),
+	}
+}
+
+func (s *tracerStats) logged(group, span, level string, now time.Time) {
+	s.total.add(level)
+	s.rates.mark(now)
+
+	gs, ok := s.groups[group]
+	if !ok {
+		gs = &groupStats{spans: make(map[string]*LevelCounts)}
+		s.groups[group] = gs
+	}
+	gs.total.add(level)
+
+	counts, ok := gs.spans[span]
+	if !ok {
+		counts = &LevelCounts{}
+		gs.spans[span] = counts
+	}
+	counts.add(level)
+}
+
+func (s *tracerStats) evictGroup(group string, spans map[string][]logEntry) {
+	s.evicted.Groups++
+	s.evicted.Spans += uint64(len(spans))
+	for _, entries := range spans {
+		s.evicted.Messages += uint64(len(entries))
+	}
+	delete(s.groups, group)
+}
+
+func (s *tracerStats) evictSpan(group, span string, numMessages int) {
+	s.evicted.Spans++
+	s.evicted.Messages += uint64(numMessages)
+	if gs, ok := s.groups[group]; ok {
+		delete(gs.spans, span)
+	}
+}
+
+// rateTickInterval is how often the moving averages are updated, as in the
+// classic unix load average.
+const rateTickInterval = 5 * time.Second
+
+// rateMeter tracks 1, 5 and 15 minute exponentially weighted moving averages
+// of an event rate. Ticks are applied lazily whenever the meter is marked or
+// read, so no background goroutine is needed.
+type rateMeter struct {
+	ewma      [3]ewma
+	uncounted uint64
+	lastTick  time.Time
+}
+
+type ewma struct {
+	alpha float64
+	rate  float64 // events per second
+	init  bool
+}
+
+func newRateMeter(now time.Time) rateMeter {
+	m := rateMeter{lastTick: now}
+	for i, minutes := range []float64{1, 5, 15} {
+		m.ewma[i].alpha = 1 - math.Exp(-rateTickInterval.Minutes()/minutes)
+	}
+	return m
+}
+
+func (m *rateMeter) mark(now time.Time) {
+	m.tick(now)
+	m.uncounted++
+}
+
+func (m *rateMeter) tick(now time.Time) {
+	ticks := int(now.Sub(m.lastTick) / rateTickInterval)
+	if ticks <= 0 {
+		return
+	}
+	m.lastTick = m.lastTick.Add(time.Duration(ticks) * rateTickInterval)
+
+	instant := float64(m.uncounted) / rateTickInterval.Seconds()
+	m.uncounted = 0
+
+	for i := range m.ewma {
+		e := &m.ewma[i]
+		if e.init {
+			e.rate += e.alpha * (instant - e.rate)
+		} else {
+			e.rate = instant
+			e.init = true
+		}
+		// the remaining ticks saw no events, so they only decay the rate
+		e.rate *= math.Pow(1-e.alpha, float64(ticks-1))
+	}
+}
@@ -0,0 +1,64 @@
+package tracer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTracerStats(t *testing.T) {
+	tcr := NewTracerWithSizes(2, 2, 2)
+
+	trace := tcr.Trace("api", "rpc")
+	trace.Info("getUser")
+	trace.Info("getUser")
+	trace.Warn("slow")
+	trace.Error("boom") // evicts "getUser"
+
+	tcr.Trace("api", "db").Info("getX")
+	tcr.Trace("api", "cache").Info("hit") // evicts span "rpc"
+	tcr.Trace("server", "run").Info("boot")
+	tcr.Trace("jobqueue", "run").Info("start") // evicts group "api"
+
+	tcr.Disable()
+	trace.Info("dropped")
+	tcr.Enable()
+
+	st := tcr.Stats()
+	assertTrue(t, st.Enabled)
+	assertEqual(t, 2, st.Groups)
+	assertEqual(t, 2, st.Spans)
+	assertEqual(t, 2, st.Messages)
+	assertEqual(t, LevelCounts{Info: 6, Warn: 1, Error: 1}, st.Logged)
+	assertEqual(t, uint64(8), st.Logged.Total())
+	assertEqual(t, uint64(1), st.Deduplicated)
+	assertEqual(t, Evictions{Groups: 1, Spans: 3, Messages: 5}, st.Evicted)
+	assertEqual(t, uint64(1), st.DroppedDisabled)
+
+	assertEqual(t, 2, len(st.PerGroup))
+	assertEqual(t, LevelCounts{Info: 1}, st.PerGroup["server"].Logged)
+	assertEqual(t, LevelCounts{Info: 1}, st.PerGroup["jobqueue"].Spans["run"])
+	_, ok := st.PerGroup["api"]
+	assertFalse(t, ok)
+}
+
+func TestRateMeter(t *testing.T) {
+	start := time.Now()
+	m := newRateMeter(start)
+
+	for i := 0; i < 50; i++ {
+		m.mark(start)
+	}
+	m.tick(start.Add(rateTickInterval))
+
+	// first tick initializes the averages to the instant rate
+	for _, e := range m.ewma {
+		assertEqual(t, 10.0, e.rate)
+	}
+
+	// idle ticks decay the short average faster than the long one
+	m.tick(start.Add(13 * rateTickInterval))
+	assertTrue(t, m.ewma[0].rate < m.ewma[1].rate)
+	assertTrue(t, m.ewma[1].rate < m.ewma[2].rate)
+	assertTrue(t, m.ewma[2].rate < 10.0)
+	assertEqual(t, uint64(0), m.uncounted)
+}
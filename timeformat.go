@@ -0,0 +1,171 @@
+package tracer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var locations sync.Map // map[string]*time.Location
+
+// LoadLocation is like time.LoadLocation, but caches the locations found,
+// so repeated calls with the same name do not hit the zoneinfo database.
+// Invalid names are not cached. An empty name is UTC.
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("tracer: invalid timezone %q: %w", name, err)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// locationOrUTC returns the named location, falling back to UTC when name
+// is not a valid timezone.
+func locationOrUTC(name string) *time.Location {
+	loc, err := LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Humanizer renders a time relative to now, such as "5m 2s ago". t is in the
+// requested timezone, and layout is the EntryFormat time layout, for
+// humanizers which switch to absolute times.
+type Humanizer interface {
+	Humanize(t, now time.Time, layout string) string
+}
+
+// HumanizerFunc adapts a function to the Humanizer interface.
+type HumanizerFunc func(t, now time.Time, layout string) string
+
+func (f HumanizerFunc) Humanize(t, now time.Time, layout string) string {
+	return f(t, now, layout)
+}
+
+// RelativeTime is the built-in Humanizer. Its zero value renders up to two
+// units with second granularity ("42s ago", "5m 0s ago", "3h ago"), and
+// absolute times after a day.
+type RelativeTime struct {
+	// Granularity is the smallest unit rendered, defaults to time.Second.
+	// Use time.Minute to render "5m ago" instead of "5m 2s ago".
+	Granularity time.Duration
+
+	// Units is the maximum number of units rendered, defaults to 2.
+	Units int
+
+	// JustNow renders durations below it as JustNowText. Disabled by
+	// default.
+	JustNow     time.Duration
+	JustNowText string // defaults to "just now"
+
+	// AbsoluteAfter renders times older than it with the layout, defaults
+	// to 24h. A negative value always renders relative times, using days
+	// as the largest unit.
+	AbsoluteAfter time.Duration
+
+	// Skew is the clock skew tolerated for times in the future, which
+	// render as now. Times further in the future render as FutureText.
+	// Defaults to one second.
+	Skew       time.Duration
+	FutureText string // defaults to "in the future"
+
+	// TrimZeroUnits drops trailing zero seconds, rendering "5m ago" instead
+	// of "5m 0s ago". Other trailing zero units are always dropped.
+	TrimZeroUnits bool
+}
+
+var relativeUnits = []struct {
+	d      time.Duration
+	suffix string
+}{
+	{24 * time.Hour, "d"},
+	{time.Hour, "h"},
+	{time.Minute, "m"},
+	{time.Second, "s"},
+}
+
+func (r RelativeTime) Humanize(t, now time.Time, layout string) string {
+	d := now.Sub(t)
+
+	if d < 0 {
+		skew := r.Skew
+		if skew <= 0 {
+			skew = time.Second
+		}
+		if -d > skew {
+			if r.FutureText == "" {
+				return "in the future"
+			}
+			return r.FutureText
+		}
+		d = 0
+	}
+
+	if d < r.JustNow {
+		if r.JustNowText == "" {
+			return "just now"
+		}
+		return r.JustNowText
+	}
+
+	absoluteAfter := r.AbsoluteAfter
+	if absoluteAfter == 0 {
+		absoluteAfter = 24 * time.Hour
+	}
+	if absoluteAfter > 0 && d >= absoluteAfter {
+		return t.Format(layout)
+	}
+
+	granularity := r.Granularity
+	if granularity < time.Second {
+		granularity = time.Second
+	}
+	maxUnits := r.Units
+	if maxUnits < 1 {
+		maxUnits = 2
+	}
+
+	d = d.Truncate(granularity)
+
+	var parts []string
+	var zeros int // trailing zero-valued parts
+	smallest := relativeUnits[0].suffix
+	for _, unit := range relativeUnits {
+		if unit.d < granularity {
+			break
+		}
+		smallest = unit.suffix
+
+		n := d / unit.d
+		d -= n * unit.d
+		if n == 0 && len(parts) == 0 {
+			continue
+		}
+		if len(parts) == maxUnits {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%d%s", n, unit.suffix))
+		if n == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	if zeros > 0 && !r.TrimZeroUnits && strings.HasSuffix(parts[len(parts)-1], "s") {
+		// "5m 0s ago" rather than "5m ago"
+		zeros = 0
+	}
+	parts = parts[:len(parts)-zeros]
+
+	if len(parts) == 0 {
+		return "0" + smallest + " ago"
+	}
+	return strings.Join(parts, " ") + " ago"
+}
@@ -0,0 +1,111 @@
+package tracer
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLoadLocation(t *testing.T) {
+	loc, err := LoadLocation("America/New_York")
+	assertNoError(t, err)
+	again, err := LoadLocation("America/New_York")
+	assertNoError(t, err)
+	assertTrue(t, loc == again)
+
+	loc, err = LoadLocation("")
+	assertNoError(t, err)
+	assertEqual(t, time.UTC, loc)
+
+	_, err = LoadLocation("Mars/Olympus_Mons")
+	assertTrue(t, err != nil)
+	assertEqual(t, time.UTC, locationOrUTC("Mars/Olympus_Mons"))
+	_, cached := locations.Load("Mars/Olympus_Mons")
+	assertFalse(t, cached)
+}
+
+func TestInvalidTimezone(t *testing.T) {
+	tcr := NewTracer()
+	tcr.Trace("api", "rpc").Info("getUser")
+
+	var buf bytes.Buffer
+	assertTrue(t, tcr.WriteJSON(&buf, JSONOptions{Timezone: "Nowhere"}) != nil)
+	assertEqual(t, 0, buf.Len())
+
+	assertTrue(t, Dump(&buf, tcr, CSVFormatter{Timezone: "Nowhere"}, "", "") != nil)
+	assertTrue(t, PublishExpvar("tracer_invalid_tz", tcr, ExpvarOptions{Timezone: "Nowhere"}) != nil)
+
+	rec := httptest.NewRecorder()
+	JSONHandler(tcr).ServeHTTP(rec, httptest.NewRequest("GET", "/?tz=Nowhere", nil))
+	assertEqual(t, 400, rec.Code)
+
+	// ToMap and the entries keep falling back to UTC, as documented
+	_, jsonOut := tcr.ToMap("Nowhere", false, "", "")
+	assertEqual(t, `{"api":{"rpc":["0s ago - [INFO] getUser"]}}`, string(jsonOut))
+	for entry := range tcr.Entries("api", "rpc") {
+		assertEqual(t, entry.FormattedMessage("UTC", true), entry.FormattedMessage("Nowhere", true))
+		assertEqual(t, entry.TimeAgo(), entry.TimeAgo("Nowhere"))
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		r    RelativeTime
+		ago  time.Duration
+		want string
+	}{
+		{RelativeTime{}, 0, "0s ago"},
+		{RelativeTime{}, 400 * time.Millisecond, "0s ago"},
+		{RelativeTime{}, 42 * time.Second, "42s ago"},
+		{RelativeTime{}, 5*time.Minute + 2*time.Second, "5m 2s ago"},
+		{RelativeTime{}, 5 * time.Minute, "5m 0s ago"},
+		{RelativeTime{}, 59*time.Minute + 400*time.Millisecond, "59m 0s ago"},
+		{RelativeTime{}, 3 * time.Hour, "3h ago"},
+		{RelativeTime{TrimZeroUnits: true}, 5 * time.Minute, "5m ago"},
+		{RelativeTime{TrimZeroUnits: true}, 5*time.Minute + 2*time.Second, "5m 2s ago"},
+		{RelativeTime{}, 3*time.Hour + 20*time.Second, "3h ago"},
+		{RelativeTime{}, 3*time.Hour + 4*time.Minute + 20*time.Second, "3h 4m ago"},
+		{RelativeTime{}, 25 * time.Hour, "09 Mar 26 11:00 UTC"},
+		{RelativeTime{}, -500 * time.Millisecond, "0s ago"},
+		{RelativeTime{}, -time.Minute, "in the future"},
+		{RelativeTime{FutureText: "soon", Skew: 2 * time.Minute}, -time.Minute, "0s ago"},
+		{RelativeTime{FutureText: "soon"}, -time.Minute, "soon"},
+		{RelativeTime{JustNow: 10 * time.Second}, 4 * time.Second, "just now"},
+		{RelativeTime{JustNow: 10 * time.Second, JustNowText: "now"}, 4 * time.Second, "now"},
+		{RelativeTime{JustNow: 10 * time.Second}, 12 * time.Second, "12s ago"},
+		{RelativeTime{Granularity: time.Minute}, 5*time.Minute + 2*time.Second, "5m ago"},
+		{RelativeTime{Granularity: time.Minute}, 30 * time.Second, "0m ago"},
+		{RelativeTime{Units: 3}, 3*time.Hour + 4*time.Minute + 20*time.Second, "3h 4m 20s ago"},
+		{RelativeTime{Units: 1}, 3*time.Hour + 4*time.Minute, "3h ago"},
+		{RelativeTime{AbsoluteAfter: -1}, 50 * time.Hour, "2d 2h ago"},
+		{RelativeTime{AbsoluteAfter: time.Hour}, 2 * time.Hour, "10 Mar 26 10:00 UTC"},
+	}
+
+	for _, c := range cases {
+		got := c.r.Humanize(now.Add(-c.ago), now, time.RFC822)
+		if got != c.want {
+			t.Errorf("%+v %v: expected %q, got %q", c.r, c.ago, c.want, got)
+		}
+	}
+}
+
+func TestEntryFormatHumanizer(t *testing.T) {
+	tcr := NewTracer()
+	tcr.Trace("api", "rpc").Info("getUser")
+	tcr.SetEntryFormat(&EntryFormat{Humanizer: RelativeTime{JustNow: time.Minute}})
+
+	for entry := range tcr.All() {
+		assertEqual(t, "just now", entry.TimeAgo())
+		assertEqual(t, "just now - [INFO] getUser", entry.FormattedMessage("UTC"))
+	}
+
+	tcr.SetEntryFormat(&EntryFormat{Humanizer: HumanizerFunc(func(t, now time.Time, layout string) string {
+		return "a while ago"
+	})})
+	for entry := range tcr.All() {
+		assertEqual(t, "a while ago", entry.TimeAgo("EST"))
+	}
+}
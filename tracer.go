@@ -2,11 +2,14 @@ package tracer
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
+	"io"
+	"iter"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,6 +19,12 @@ const (
 	DefaultMessageCount = 60 // total messages per span
 )
 
+const (
+	LevelInfo  = "INFO"
+	LevelWarn  = "WARN"
+	LevelError = "ERROR"
+)
+
 type Tracer interface {
 	Trace(group, span string) Logger
 	Group(group string) Logger
@@ -24,16 +33,75 @@ type Tracer interface {
 	ListSpans(group string) []string
 
 	Logs(group string) [][]LogEntry
+
+	// ToMap renders times in timezone, falling back to UTC when it is not a
+	// valid timezone. Use WriteJSON, or LoadLocation beforehand, to have
+	// an invalid timezone reported.
 	ToMap(timezone string, withExactTime bool, groupFilter, spanFilter string) (map[string]map[string][]string, []byte)
+	WriteJSON(w io.Writer, opts JSONOptions) error
+
+	// Iterators over a consistent snapshot of the tracer contents, in
+	// recency order (most recently updated first).
+	All() iter.Seq[LogEntry]
+	Groups() iter.Seq[string]
+	Spans(group string) iter.Seq[string]
+	Entries(group, span string) iter.Seq[LogEntry]
+
+	Stats() Stats
+
+	// SetEntryFormat customizes how FormattedMessage renders the entries of
+	// this tracer. A nil format restores the default.
+	SetEntryFormat(format *EntryFormat)
+
+	// SetRedactor sets the redactor applied to messages and attrs before
+	// they are stored. A nil redactor disables redaction.
+	SetRedactor(r *Redactor)
 
 	Enable()  // by default tracer is enabled
 	Disable() // disable all logging, turning each call into a noop
 	IsEnabled() bool
+
+	// SetMinLevel drops messages of group below level. An empty level
+	// removes the minimum.
+	SetMinLevel(group, level string) error
+
+	// SetSampling replaces the sampling rules, which decide whether to keep
+	// messages before the lock is taken. No rules disable sampling.
+	SetSampling(rules ...SamplingRule)
+
+	// SetBurstSuppression enables burst suppression of spans, or disables
+	// it if opts is nil. See BurstOptions.
+	SetBurstSuppression(opts *BurstOptions)
+
+	// Pin exempts group from eviction, Unpin reverts it.
+	Pin(group string)
+	Unpin(group string)
+
+	// Reset removes all groups and resets the stats counters. Settings
+	// such as pins, minimum levels, the entry format and the redactor are
+	// kept.
+	Reset()
+
+	// DeleteGroup and DeleteSpan remove a group or a span, reporting
+	// whether it existed. A group left without spans is removed.
+	DeleteGroup(group string) bool
+	DeleteSpan(group, span string) bool
+
+	// DeleteWhere removes the entries matching q, and any span or group
+	// left empty, returning the number of entries removed.
+	DeleteWhere(q Query) int
+
+	// Subscribe calls fn with every entry stored, including each repeat of
+	// a deduplicated entry, until the returned func is called. fn runs on
+	// the goroutine of the logger once the tracer lock is released, and
+	// should not block.
+	Subscribe(fn func(LogEntry)) (unsubscribe func())
 }
 
 type Logger interface {
 	Span(span string) Logger
 	With(group, span string) Logger
+	WithAttrs(attrs ...Attr) Logger
 
 	GetGroup() string
 	GetSpan() string
@@ -49,8 +117,17 @@ type LogEntry interface {
 	Span() string
 	Message() string
 	Time() time.Time
+
+	// TimeAgo renders the time of the entry in timezone, UTC by default,
+	// falling back to UTC when it is not a valid timezone. Use LoadLocation
+	// to validate a timezone beforehand.
 	TimeAgo(timezone ...string) string
+
 	Count() uint32
+	Attrs() []Attr
+
+	// FormattedMessage renders the entry with its times in timezone, with
+	// the same fallback to UTC as TimeAgo.
 	FormattedMessage(timezone string, withExactTime ...bool) string
 }
 
@@ -60,6 +137,19 @@ type tracer struct {
 	enabled                          bool
 	groupTS                          map[string]time.Time
 	spanTS                           map[string]map[string]time.Time
+	stats                            tracerStats
+	droppedDisabled                  atomic.Uint64
+	entryFormat                      atomic.Pointer[EntryFormat]
+	redactor                         atomic.Pointer[Redactor]
+	minLevels                        atomic.Pointer[map[string]int]
+	droppedLevel                     atomic.Uint64
+	sampling                         atomic.Pointer[sampling]
+	droppedSampled                   atomic.Uint64
+	burstOptions                     atomic.Pointer[BurstOptions]
+	bursts                           map[string]map[string]*burstState
+	pinned                           map[string]bool
+	subscribers                      atomic.Pointer[[]*subscriber]
+	observers                        atomic.Pointer[[]*subscriber]
 	mu                               sync.RWMutex
 }
 
@@ -86,6 +176,9 @@ func NewTracerWithSizes(numGroups, numSpans, numMessages int) Tracer {
 		enabled:     true,
 		groupTS:     make(map[string]time.Time),
 		spanTS:      make(map[string]map[string]time.Time),
+		stats:       newTracerStats(),
+		pinned:      make(map[string]bool),
+		bursts:      make(map[string]map[string]*burstState),
 	}
 }
 
@@ -140,27 +233,15 @@ func (t *tracer) Logs(group string) [][]LogEntry {
 		return [][]LogEntry{}
 	}
 
-	spans := make([]string, 0, len(t.logs[group]))
-	for span := range t.logs[group] {
-		spans = append(spans, span)
-	}
-
-	sort.Slice(spans, func(i, j int) bool {
-		timeI := t.spanTS[group][spans[i]]
-		timeJ := t.spanTS[group][spans[j]]
-		return timeI.After(timeJ) // most recent first
-	})
+	spans := t.sortedSpans(group, "")
 
 	out := make([][]LogEntry, 0, len(spans))
 	for _, span := range spans {
-		entries := t.logs[group][span]
+		entries := sortedEntries(t.logs[group][span])
 		outSpan := make([]LogEntry, 0, len(entries))
 		for _, entry := range entries {
 			outSpan = append(outSpan, entry)
 		}
-		sort.Slice(outSpan, func(i, j int) bool {
-			return outSpan[i].Time().After(outSpan[j].Time())
-		})
 		out = append(out, outSpan)
 	}
 
@@ -168,15 +249,32 @@ func (t *tracer) Logs(group string) [][]LogEntry {
 }
 
 func (t *tracer) ToMap(timezone string, withExactTime bool, groupFilter, spanFilter string) (map[string]map[string][]string, []byte) {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-
 	var m = make(map[string]map[string][]string)
 	var jsonBuf bytes.Buffer
 
-	// custom json output to ensure desired ordering of map keys
-	jsonBuf.WriteString(`{`)
+	opts := JSONOptions{
+		Timezone:      timezone,
+		WithExactTime: withExactTime,
+		GroupFilter:   groupFilter,
+		SpanFilter:    spanFilter,
+	}
+
+	snap := t.snapshot(groupFilter, spanFilter)
+	for _, group := range snap {
+		m[group.group] = make(map[string][]string, len(group.spans))
+	}
+
+	// writing to a bytes.Buffer never fails
+	_ = writeJSON(&jsonBuf, snap, locationOrUTC(timezone), opts, func(group, span string, entries []string) {
+		m[group][span] = entries
+	})
 
+	return m, jsonBuf.Bytes()
+}
+
+// sortedGroups returns the names of the groups matching groupFilter as a
+// prefix, most recently updated first. The caller must hold t.mu.
+func (t *tracer) sortedGroups(groupFilter string) []string {
 	groups := make([]string, 0, len(t.logs))
 	for group := range t.logs {
 		if groupFilter != "" && !strings.HasPrefix(group, groupFilter) {
@@ -191,62 +289,37 @@ func (t *tracer) ToMap(timezone string, withExactTime bool, groupFilter, spanFil
 		return timeI.After(timeJ) // most recent first
 	})
 
-	for i, group := range groups {
-		if i > 0 {
-			jsonBuf.WriteString(`,`)
-		}
-		v, _ := json.Marshal(group)
-		jsonBuf.WriteString(fmt.Sprintf(`%s:{`, v))
-
-		spans := t.logs[group]
-
-		spanNames := make([]string, 0, len(spans))
-		for span := range spans {
-			if spanFilter != "" && !strings.HasPrefix(span, spanFilter) {
-				continue
-			}
-			spanNames = append(spanNames, span)
-		}
-
-		sort.Slice(spanNames, func(i, j int) bool {
-			timeI := t.spanTS[group][spanNames[i]]
-			timeJ := t.spanTS[group][spanNames[j]]
-			return timeI.After(timeJ) // most recent first
-		})
-
-		groupMap := make(map[string][]string)
-		for j, span := range spanNames {
-			if j > 0 {
-				jsonBuf.WriteString(`,`)
-			}
-			v, _ := json.Marshal(span)
-			jsonBuf.WriteString(fmt.Sprintf(`%s:`, v))
-
-			originalEntries := spans[span]
-			sortedEntries := make([]logEntry, len(originalEntries))
-			copy(sortedEntries, originalEntries)
-			sort.Slice(sortedEntries, func(i, j int) bool {
-				return sortedEntries[i].time.After(sortedEntries[j].time) // Most recent first
-			})
-
-			formattedEntries := make([]string, 0, len(sortedEntries))
-			for _, entry := range sortedEntries {
-				formattedEntries = append(formattedEntries, entry.FormattedMessage(timezone, withExactTime))
-			}
-			groupMap[span] = formattedEntries
+	return groups
+}
 
-			vs, _ := json.Marshal(formattedEntries)
-			jsonBuf.Write(vs)
+// sortedSpans returns the names of the spans in group matching spanFilter as
+// a prefix, most recently updated first. The caller must hold t.mu.
+func (t *tracer) sortedSpans(group, spanFilter string) []string {
+	spans := make([]string, 0, len(t.logs[group]))
+	for span := range t.logs[group] {
+		if spanFilter != "" && !strings.HasPrefix(span, spanFilter) {
+			continue
 		}
-
-		jsonBuf.WriteString(`}`)
-
-		m[group] = groupMap
+		spans = append(spans, span)
 	}
 
-	jsonBuf.WriteString(`}`)
+	sort.Slice(spans, func(i, j int) bool {
+		timeI := t.spanTS[group][spans[i]]
+		timeJ := t.spanTS[group][spans[j]]
+		return timeI.After(timeJ) // most recent first
+	})
 
-	return m, jsonBuf.Bytes()
+	return spans
+}
+
+// sortedEntries returns a copy of entries, most recent first.
+func sortedEntries(entries []logEntry) []logEntry {
+	out := make([]logEntry, len(entries))
+	copy(out, entries)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].time.After(out[j].time) // most recent first
+	})
+	return out
 }
 
 func (t *tracer) Enable() {
@@ -267,10 +340,164 @@ func (t *tracer) IsEnabled() bool {
 	return t.enabled
 }
 
+func (t *tracer) SetMinLevel(group, level string) error {
+	rank := levelRank(level)
+	if level != "" && rank == 0 {
+		return fmt.Errorf("tracer: invalid level %q", level)
+	}
+
+	// copy on write, so log can read the levels without locking
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	levels := make(map[string]int)
+	if old := t.minLevels.Load(); old != nil {
+		for g, r := range *old {
+			levels[g] = r
+		}
+	}
+	if rank == 0 {
+		delete(levels, group)
+	} else {
+		levels[group] = rank
+	}
+	t.minLevels.Store(&levels)
+	return nil
+}
+
+// belowMinLevel reports whether level is below the minimum level of group.
+func (t *tracer) belowMinLevel(group, level string) bool {
+	levels := t.minLevels.Load()
+	if levels == nil {
+		return false
+	}
+	min, ok := (*levels)[group]
+	return ok && levelRank(level) < min
+}
+
+type subscriber struct {
+	fn func(LogEntry)
+}
+
+func (t *tracer) Subscribe(fn func(LogEntry)) func() {
+	return t.addSubscriber(&t.subscribers, fn)
+}
+
+// observe calls fn with every message logged while the tracer is enabled and
+// above the minimum level, before sampling, and with every audit entry,
+// until the returned func is called. Unlike Subscribe, it sees the messages
+// dropped by sampling, and each repeat is passed with a count of 1.
+func (t *tracer) observe(fn func(LogEntry)) func() {
+	return t.addSubscriber(&t.observers, fn)
+}
+
+// observeEntries calls fn with every message logged into t, including those
+// dropped by sampling, when t is a tracer of this package. Other tracers
+// only report the entries they store, through Subscribe.
+func observeEntries(t Tracer, fn func(LogEntry)) func() {
+	if t, ok := t.(*tracer); ok {
+		return t.observe(fn)
+	}
+	return t.Subscribe(fn)
+}
+
+// addSubscriber adds fn to subs, and returns the func removing it.
+func (t *tracer) addSubscriber(subs *atomic.Pointer[[]*subscriber], fn func(LogEntry)) func() {
+	sub := &subscriber{fn: fn}
+
+	// subscribers are replaced copy-on-write, so that notify needs no lock
+	t.mu.Lock()
+	var next []*subscriber
+	if cur := subs.Load(); cur != nil {
+		next = slices.Clone(*cur)
+	}
+	next = append(next, sub)
+	subs.Store(&next)
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if cur := subs.Load(); cur != nil {
+			next := slices.DeleteFunc(slices.Clone(*cur), func(s *subscriber) bool { return s == sub })
+			if len(next) == 0 {
+				subs.Store(nil)
+			} else {
+				subs.Store(&next)
+			}
+		}
+	}
+}
+
+// notify calls the subscribers with entry. The caller must not hold t.mu.
+func (t *tracer) notify(entry logEntry) {
+	if subs := t.subscribers.Load(); subs != nil {
+		notify(subs, entry)
+	}
+}
+
+func notify(subs *[]*subscriber, entry logEntry) {
+	for _, sub := range *subs {
+		sub.fn(entry)
+	}
+}
+
+func (t *tracer) Pin(group string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pinned[group] = true
+}
+
+func (t *tracer) Unpin(group string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pinned, group)
+}
+
+// deleteGroup removes group and its spans. The caller must hold t.mu.
+func (t *tracer) deleteGroup(group string) {
+	delete(t.logs, group)
+	delete(t.groupTS, group)
+	delete(t.spanTS, group)
+	delete(t.stats.groups, group)
+	delete(t.bursts, group)
+}
+
+// deleteSpan removes a span of group, and the group if it has no spans left.
+// The caller must hold t.mu.
+func (t *tracer) deleteSpan(group, span string) {
+	delete(t.logs[group], span)
+	delete(t.spanTS[group], span)
+	delete(t.bursts[group], span)
+	if gs, ok := t.stats.groups[group]; ok {
+		delete(gs.spans, span)
+	}
+	if len(t.logs[group]) == 0 {
+		t.deleteGroup(group)
+	}
+}
+
+var levelNames = []string{"", LevelInfo, LevelWarn, LevelError}
+
+// levelRank orders levels by severity, with 0 for unknown levels.
+func levelRank(level string) int {
+	switch level {
+	case LevelInfo:
+		return 1
+	case LevelWarn:
+		return 2
+	case LevelError:
+		return 3
+	default:
+		return 0
+	}
+}
+
 type logger struct {
 	tracer *tracer
 	group  string
 	span   string
+	attrs  []Attr
 }
 
 var _ Logger = &logger{}
@@ -280,6 +507,7 @@ func (l *logger) Span(span string) Logger {
 		tracer: l.tracer,
 		group:  l.group,
 		span:   span,
+		attrs:  l.attrs,
 	}
 }
 
@@ -288,6 +516,19 @@ func (l *logger) With(group, span string) Logger {
 		tracer: l.tracer,
 		group:  group,
 		span:   span,
+		attrs:  l.attrs,
+	}
+}
+
+// WithAttrs returns a logger which attaches attrs, after those of l, to the
+// entries it logs. When a message is deduplicated, the entry keeps the attrs
+// of the latest occurrence.
+func (l *logger) WithAttrs(attrs ...Attr) Logger {
+	return &logger{
+		tracer: l.tracer,
+		group:  l.group,
+		span:   l.span,
+		attrs:  append(slices.Clip(l.attrs), attrs...),
 	}
 }
 
@@ -300,63 +541,132 @@ func (l *logger) GetSpan() string {
 }
 
 func (l *logger) Info(message string, v ...any) {
-	l.log("INFO", l.group, l.span, message, v...)
+	l.log(LevelInfo, l.group, l.span, message, v...)
 }
 
 func (l *logger) Warn(message string, v ...any) {
-	l.log("WARN", l.group, l.span, message, v...)
+	l.log(LevelWarn, l.group, l.span, message, v...)
 }
 
 func (l *logger) Error(message string, v ...any) {
-	l.log("ERROR", l.group, l.span, message, v...)
+	l.log(LevelError, l.group, l.span, message, v...)
 }
 
 func (l *logger) log(level, group, span, message string, v ...any) {
 	if !l.tracer.IsEnabled() {
+		l.tracer.droppedDisabled.Add(1)
 		return
 	}
+	if l.tracer.belowMinLevel(group, level) {
+		l.tracer.droppedLevel.Add(1)
+		return
+	}
+
+	// Observers see every message, so format it before sampling for them
+	var msg string
+	var attrs []Attr
+	observers := l.tracer.observers.Load()
+	if observers != nil {
+		msg, attrs = l.format(message, v)
+		if msg != "" {
+			notify(observers, logEntry{group: group, span: span, message: msg, level: level, time: time.Now().UTC(), count: 1, attrs: attrs, tracer: l.tracer})
+		}
+	}
 
-	l.tracer.mu.Lock()
-	defer l.tracer.mu.Unlock()
+	sampled := uint32(1)
+	if s := l.tracer.sampling.Load(); s != nil {
+		n, keep := s.sample(group, span, level, time.Now())
+		if !keep {
+			l.tracer.droppedSampled.Add(1)
+			return
+		}
+		sampled = n
+	}
+
+	// Format and redact the message before taking the lock
+	if observers == nil {
+		msg, attrs = l.format(message, v)
+	}
+	l.tracer.store(level, group, span, msg, attrs, sampled, true)
+}
+
+// format returns the message and attrs of l, redacted.
+func (l *logger) format(message string, v []any) (string, []Attr) {
+	msg := fmt.Sprintf(message, v...)
+	attrs := l.attrs
+	if r := l.tracer.redactor.Load(); r != nil {
+		msg = r.Redact(msg)
+		attrs = r.RedactAttrs(attrs)
+	}
+	return msg, attrs
+}
+
+// audit records msg as an INFO entry whatever the configuration: it bypasses
+// the enabled flag, minimum levels, sampling, burst suppression and the
+// redactor, so that changes to those are always recorded.
+func (t *tracer) audit(group, span, msg string) {
+	if observers := t.observers.Load(); observers != nil {
+		notify(observers, logEntry{group: group, span: span, message: msg, level: LevelInfo, time: time.Now().UTC(), count: 1, tracer: t})
+	}
+	t.store(LevelInfo, group, span, msg, nil, 1, false)
+}
+
+// store records a formatted message in the span, deduplicating it and
+// applying the size limits. suppress applies burst suppression to new
+// entries.
+func (t *tracer) store(level, group, span, msg string, attrs []Attr, sampled uint32, suppress bool) {
+	// Notify subscribers of the stored entry, if any, after unlocking
+	var stored *logEntry
+	defer func() {
+		if stored != nil {
+			t.notify(*stored)
+		}
+	}()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
 	timeNow := time.Now().UTC()
 
 	// Ensure group exists and handle group limit
-	if _, ok := l.tracer.logs[group]; !ok {
-		if len(l.tracer.groupTS) >= l.tracer.numGroups && l.tracer.numGroups > 0 {
-			// Find and remove the oldest group
+	if _, ok := t.logs[group]; !ok {
+		if len(t.groupTS) >= t.numGroups && t.numGroups > 0 {
+			// Find and remove the oldest group. Pinned groups are never
+			// evicted, so they may take the group count over the limit.
 			var oldestGroup string
 			var oldestTime time.Time
 			first := true
-			for grp, ts := range l.tracer.groupTS {
+			for grp, ts := range t.groupTS {
+				if t.pinned[grp] {
+					continue
+				}
 				if first || ts.Before(oldestTime) {
 					oldestGroup = grp
 					oldestTime = ts
 					first = false
 				}
 			}
-			if oldestGroup != "" { // Ensure we found one
-				delete(l.tracer.logs, oldestGroup)
-				delete(l.tracer.groupTS, oldestGroup)
-				delete(l.tracer.spanTS, oldestGroup)
+			if !first { // Ensure we found one
+				t.stats.evictGroup(oldestGroup, t.logs[oldestGroup])
+				t.deleteGroup(oldestGroup)
 			}
 		}
 		// Create the new group structures
-		l.tracer.logs[group] = make(map[string][]logEntry)
-		l.tracer.spanTS[group] = make(map[string]time.Time)
+		t.logs[group] = make(map[string][]logEntry)
+		t.spanTS[group] = make(map[string]time.Time)
 	}
 	// Update group timestamp regardless of whether it was new or existing
-	l.tracer.groupTS[group] = timeNow
+	t.groupTS[group] = timeNow
 
 	// Ensure span exists and handle span limit
-	_, spanExists := l.tracer.logs[group][span]
+	_, spanExists := t.logs[group][span]
 	if !spanExists {
-		if len(l.tracer.spanTS[group]) >= l.tracer.numSpans && l.tracer.numSpans > 0 {
+		if len(t.spanTS[group]) >= t.numSpans && t.numSpans > 0 {
 			// Find and remove the oldest span in this group
 			var oldestSpan string
 			var oldestTime time.Time
 			first := true
-			for sp, ts := range l.tracer.spanTS[group] {
+			for sp, ts := range t.spanTS[group] {
 				if first || ts.Before(oldestTime) {
 					oldestSpan = sp
 					oldestTime = ts
@@ -364,22 +674,23 @@ func (l *logger) log(level, group, span, message string, v ...any) {
 				}
 			}
 			if oldestSpan != "" { // Ensure we found one
-				delete(l.tracer.logs[group], oldestSpan)
-				delete(l.tracer.spanTS[group], oldestSpan)
+				t.stats.evictSpan(group, oldestSpan, len(t.logs[group][oldestSpan]))
+				delete(t.logs[group], oldestSpan)
+				delete(t.spanTS[group], oldestSpan)
+				delete(t.bursts[group], oldestSpan)
 			}
 		}
 		// Create the new span slice (it will be populated later)
 		// Ensure the map entry exists even if the slice is initially empty
-		l.tracer.logs[group][span] = make([]logEntry, 0, l.tracer.numMessages)
+		t.logs[group][span] = make([]logEntry, 0, t.numMessages)
 	}
 	// Update span timestamp regardless of whether it was new or existing
-	l.tracer.spanTS[group][span] = timeNow
+	t.spanTS[group][span] = timeNow
 
 	// Log entry handling
-	s := l.tracer.logs[group][span] // Get the (potentially new) span slice
+	s := t.logs[group][span] // Get the (potentially new) span slice
 
-	// Format message and apply length limit
-	msg := fmt.Sprintf(message, v...)
+	// Apply message length limit
 	if len(msg) == 0 {
 		return // Don't log empty messages
 	}
@@ -388,6 +699,8 @@ func (l *logger) log(level, group, span, message string, v ...any) {
 		msg = msg[:maxMsgLen] // truncate
 	}
 
+	t.stats.logged(group, span, level, timeNow)
+
 	// Check for duplicate message to increment count instead of adding new entry
 	found := false
 	for i := range s {
@@ -395,7 +708,12 @@ func (l *logger) log(level, group, span, message string, v ...any) {
 		if s[i].message == msg && s[i].level == level {
 			s[i].count++
 			s[i].time = timeNow
-			l.tracer.logs[group][span] = s
+			s[i].attrs = attrs
+			s[i].sampled = sampled
+			t.logs[group][span] = s
+			t.stats.deduplicated++
+			entry := s[i]
+			stored = &entry
 			found = true
 			break
 		}
@@ -403,24 +721,33 @@ func (l *logger) log(level, group, span, message string, v ...any) {
 
 	// If it wasn't a duplicate, add a new entry
 	if !found {
+		if suppress {
+			s = t.suppressBurst(group, span, s, timeNow)
+		}
 		newEntry := logEntry{
-			group:   l.group,
-			span:    l.span,
+			group:   group,
+			span:    span,
 			message: msg,
 			level:   level,
 			time:    timeNow,
 			count:   1,
+			sampled: sampled,
+			attrs:   attrs,
+			tracer:  t,
 		}
 		// Handle message limit using FIFO eviction
-		if len(s) < l.tracer.numMessages {
+		if len(s) < t.numMessages {
 			s = append(s, newEntry)
-		} else if l.tracer.numMessages > 0 {
+			stored = &newEntry
+		} else if t.numMessages > 0 {
 			s = append(s[1:], newEntry)
+			t.stats.evicted.Messages++
+			stored = &newEntry
 		} else {
 			// If numMessages is 0, effectively disable message logging for this span
 			s = []logEntry{}
 		}
-		l.tracer.logs[group][span] = s
+		t.logs[group][span] = s
 	}
 }
 
@@ -431,10 +758,23 @@ type logEntry struct {
 	level   string
 	time    time.Time
 	count   uint32
+	sampled uint32 // messages stood for by the last occurrence, see SetSampling
+	summary bool   // summary of the current burst, see SetBurstSuppression
+	attrs   []Attr
+	tracer  *tracer
 }
 
 var _ LogEntry = logEntry{}
 
+// sampledCount returns the number of messages the entry stands for when
+// sampled, and zero otherwise.
+func (l logEntry) sampledCount() uint32 {
+	if l.sampled > 1 {
+		return l.sampled
+	}
+	return 0
+}
+
 func (l logEntry) Group() string {
 	return l.group
 }
@@ -456,52 +796,35 @@ func (l logEntry) Time() time.Time {
 }
 
 func (l logEntry) TimeAgo(timezone ...string) string {
-	var err error
 	loc := time.UTC
 	if len(timezone) > 0 {
-		loc, err = time.LoadLocation(timezone[0])
-		if err != nil {
-			loc = time.UTC
-		}
-	}
-
-	duration := time.Since(l.time.In(loc))
-
-	if duration < time.Minute {
-		return fmt.Sprintf("%ds ago", int(duration.Seconds()))
-	} else if duration < time.Hour {
-		seconds := int(duration.Seconds()) % 60
-		return fmt.Sprintf("%dm %ds ago", int(duration.Minutes()), seconds)
-	} else if duration < 24*time.Hour {
-		hours := int(duration.Hours())
-		minutes := int(duration.Minutes()) % 60
-		if minutes == 0 {
-			return fmt.Sprintf("%dh ago", hours)
-		}
-		return fmt.Sprintf("%dh %dm ago", hours, minutes)
+		loc = locationOrUTC(timezone[0])
 	}
+	return l.timeAgo(loc, time.Now())
+}
 
-	return l.time.In(loc).Format(time.RFC822)
+func (l logEntry) timeAgo(loc *time.Location, now time.Time) string {
+	f := l.entryFormat()
+	return f.humanizer().Humanize(l.time.In(loc), now, f.timeLayout())
 }
 
 func (l logEntry) Count() uint32 {
 	return l.count
 }
 
+func (l logEntry) Attrs() []Attr {
+	return slices.Clone(l.attrs)
+}
+
 func (l logEntry) FormattedMessage(timezone string, withExactTime ...bool) string {
-	loc, err := time.LoadLocation(timezone)
-	if err != nil {
-		loc = time.UTC
-	}
-	var out string
-	if len(withExactTime) > 0 && withExactTime[0] {
-		out = fmt.Sprintf("%s - [%s] %s", l.time.In(loc).Format(time.RFC822), l.level, l.message)
-	} else {
-		out = fmt.Sprintf("%s - [%s] %s", l.TimeAgo(timezone), l.level, l.message)
-	}
-	if l.count > 1 {
-		return fmt.Sprintf("%s [x%d]", out, l.count)
-	} else {
-		return out
+	return l.entryFormat().render(l, locationOrUTC(timezone), len(withExactTime) > 0 && withExactTime[0])
+}
+
+// entryFormat returns the format set on the tracer of the entry, or nil for
+// the default format.
+func (l logEntry) entryFormat() *EntryFormat {
+	if l.tracer == nil {
+		return nil
 	}
+	return l.tracer.entryFormat.Load()
 }
@@ -269,3 +269,25 @@ func TestTracerConcurrency(t *testing.T) {
 		}
 	}
 }
+
+func TestSubscribe(t *testing.T) {
+	tcr := NewTracer()
+
+	var got []string
+	unsubscribe := tcr.Subscribe(func(entry LogEntry) {
+		got = append(got, fmt.Sprintf("%s/%s %s x%d", entry.Group(), entry.Span(), entry.Message(), entry.Count()))
+		// the lock is released, so the tracer may be used
+		tcr.Stats()
+	})
+
+	tcr.Trace("g", "s").Info("a")
+	tcr.Trace("g", "s").Info("a")
+	tcr.Trace("g", "s").Info("")
+	tcr.Disable()
+	tcr.Trace("g", "s").Info("b")
+	tcr.Enable()
+	unsubscribe()
+	tcr.Trace("g", "s").Info("c")
+
+	assertEqual(t, []string{"g/s a x1", "g/s a x2"}, got)
+}
@@ -0,0 +1,119 @@
+package tracer
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TransportOptions configures NewTransport.
+type TransportOptions struct {
+	// Base is the RoundTripper making the requests, defaults to
+	// http.DefaultTransport.
+	Base http.RoundTripper
+
+	// Group returns the group of a request, defaults to the target host.
+	Group func(r *http.Request) string
+
+	// Span returns the span of a request, defaults to the method and the
+	// target host, such as "GET api.example.com". The path is recorded in
+	// the url attr rather than the span, as paths often embed ids.
+	Span func(r *http.Request) string
+
+	// RedactedParams are the query parameters whose values are redacted from
+	// the recorded URL, and from the URLs in error messages, compared case
+	// insensitively. Defaults to DefaultRedactedKeys. The password of URLs
+	// is always redacted.
+	RedactedParams []string
+}
+
+// NewTransport returns an http.RoundTripper recording each request made
+// through it into t, with its method, redacted URL, status code and
+// duration. Responses with 4xx statuses are recorded at WARN level, and 5xx
+// statuses and transport errors at ERROR level.
+func NewTransport(t Tracer, opts TransportOptions) http.RoundTripper {
+	if opts.Base == nil {
+		opts.Base = http.DefaultTransport
+	}
+	if opts.Group == nil {
+		opts.Group = func(r *http.Request) string { return r.URL.Host }
+	}
+	if opts.Span == nil {
+		opts.Span = func(r *http.Request) string { return r.Method + " " + r.URL.Host }
+	}
+	if opts.RedactedParams == nil {
+		opts.RedactedParams = DefaultRedactedKeys
+	}
+	params := make(map[string]bool, len(opts.RedactedParams))
+	for _, p := range opts.RedactedParams {
+		params[strings.ToLower(p)] = true
+	}
+	return &transport{tracer: t, opts: opts, params: params}
+}
+
+type transport struct {
+	tracer Tracer
+	opts   TransportOptions
+	params map[string]bool
+}
+
+func (tr *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := tr.opts.Base.RoundTrip(r)
+	duration := time.Since(start)
+
+	l := tr.tracer.Trace(tr.opts.Group(r), tr.opts.Span(r))
+	attrs := []Attr{
+		{"method", r.Method},
+		{"url", redactURL(r.URL, tr.params)},
+	}
+	if err != nil {
+		msg := redactURLs(err.Error(), tr.params)
+		l.WithAttrs(append(attrs, Attr{"duration", duration.String()}, Attr{"error", msg})...).
+			Error("error: %s", msg)
+		return resp, err
+	}
+
+	l = l.WithAttrs(append(attrs,
+		Attr{"status", strconv.Itoa(resp.StatusCode)},
+		Attr{"duration", duration.String()},
+	)...)
+	logStatus(l, resp.StatusCode)
+	return resp, nil
+}
+
+// redactURL returns u without its password and with the values of the given
+// query parameters redacted, keeping the order of the parameters.
+func redactURL(u *url.URL, params map[string]bool) string {
+	redacted := *u
+	pairs := strings.Split(u.RawQuery, "&")
+	for i, pair := range pairs {
+		raw, _, ok := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(raw)
+		if err != nil {
+			key = raw
+		}
+		if ok && params[strings.ToLower(key)] {
+			pairs[i] = raw + "=" + RedactedText
+		}
+	}
+	redacted.RawQuery = strings.Join(pairs, "&")
+	return redacted.Redacted()
+}
+
+var urlPattern = regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+
+// redactURLs applies redactURL to the URLs found in s, such as those of
+// error messages.
+func redactURLs(s string, params map[string]bool) string {
+	return urlPattern.ReplaceAllStringFunc(s, func(match string) string {
+		u, err := url.Parse(match)
+		if err != nil {
+			return RedactedText
+		}
+		return redactURL(u, params)
+	})
+}
@@ -0,0 +1,72 @@
+package tracer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// WriteZipkin writes the tracer contents as a Zipkin v2 JSON span list,
+// which can be posted to /api/v2/spans or opened in the Zipkin UI.
+//
+// Each group becomes a trace with the group as the local service name, each
+// span a span covering its oldest to most recent entry, and each entry an
+// annotation.
+func WriteZipkin(w io.Writer, t Tracer) error {
+	spans := []zipkinSpan{}
+	for _, g := range collectGroups(t) {
+		for _, s := range g.spans {
+			span := zipkinSpan{
+				TraceID:       traceID(g.name),
+				ID:            spanID(g.name, s.name),
+				Name:          s.name,
+				Timestamp:     s.start().UnixMicro(),
+				Duration:      max(s.end().Sub(s.start()).Microseconds(), 1),
+				LocalEndpoint: zipkinEndpoint{ServiceName: g.name},
+				Annotations:   make([]zipkinAnnotation, 0, len(s.entries)),
+				Tags:          map[string]string{"tracelog.entries": strconv.Itoa(len(s.entries))},
+			}
+			for _, entry := range s.entries {
+				span.Annotations = append(span.Annotations, zipkinAnnotation{
+					Timestamp: entry.Time().UnixMicro(),
+					Value:     annotationValue(entry),
+				})
+			}
+			if s.hasErrors() {
+				span.Tags["error"] = "true"
+			}
+			spans = append(spans, span)
+		}
+	}
+	return json.NewEncoder(w).Encode(spans)
+}
+
+// annotationValue renders an entry as a single line, without its time which
+// the annotation carries separately.
+func annotationValue(entry LogEntry) string {
+	if entry.Count() > 1 {
+		return fmt.Sprintf("[%s] %s [x%d]", entry.Level(), entry.Message(), entry.Count())
+	}
+	return fmt.Sprintf("[%s] %s", entry.Level(), entry.Message())
+}
+
+type zipkinSpan struct {
+	TraceID       string             `json:"traceId"`
+	ID            string             `json:"id"`
+	Name          string             `json:"name"`
+	Timestamp     int64              `json:"timestamp"`
+	Duration      int64              `json:"duration"`
+	LocalEndpoint zipkinEndpoint     `json:"localEndpoint"`
+	Annotations   []zipkinAnnotation `json:"annotations"`
+	Tags          map[string]string  `json:"tags"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
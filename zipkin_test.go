@@ -0,0 +1,46 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestWriteZipkin(t *testing.T) {
+	tcr := NewTracer()
+	tcr.Trace("api", "rpc").Info("getUser")
+	time.Sleep(time.Millisecond)
+	tcr.Trace("api", "rpc").Error("boom")
+	tcr.Trace("api", "rpc").Error("boom")
+	tcr.Trace("api", "db").Info("getX")
+
+	var buf bytes.Buffer
+	assertNoError(t, WriteZipkin(&buf, tcr))
+
+	var spans []zipkinSpan
+	assertNoError(t, json.Unmarshal(buf.Bytes(), &spans))
+	assertEqual(t, 2, len(spans))
+
+	db, rpc := spans[0], spans[1]
+	assertEqual(t, "db", db.Name)
+	assertEqual(t, int64(1), db.Duration)
+	assertEqual(t, "", db.Tags["error"])
+
+	assertEqual(t, "rpc", rpc.Name)
+	assertEqual(t, "api", rpc.LocalEndpoint.ServiceName)
+	assertEqual(t, db.TraceID, rpc.TraceID)
+	assertTrue(t, db.ID != rpc.ID)
+	assertEqual(t, spanID("api", "rpc"), rpc.ID)
+	assertTrue(t, rpc.Duration >= 1000)
+	assertEqual(t, "true", rpc.Tags["error"])
+	assertEqual(t, "2", rpc.Tags["tracelog.entries"])
+	assertEqual(t, "[INFO] getUser", rpc.Annotations[0].Value)
+	assertEqual(t, "[ERROR] boom [x2]", rpc.Annotations[1].Value)
+	assertEqual(t, rpc.Timestamp, rpc.Annotations[0].Timestamp)
+
+	// ids are stable across exports
+	var again bytes.Buffer
+	assertNoError(t, WriteZipkin(&again, tcr))
+	assertEqual(t, buf.String(), again.String())
+}